@@ -0,0 +1,175 @@
+package express
+
+import (
+	"strings"
+	"time"
+)
+
+// arrayArg returns the argument at the index as an array.
+func arrayArg(args []any, i int) ([]any, error) {
+	arr, ok := args[i].([]any)
+	if !ok {
+		return nil, ErrInvalidArgument{Index: i, Value: args[i], Expected: "array"}
+	}
+	return arr, nil
+}
+
+// numericElements returns the numbers in the array argument, skipping nulls.
+func numericElements(args []any) ([]float64, error) {
+	arr, err := arrayArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, 0, len(arr))
+	for _, v := range arr {
+		switch t := v.(type) {
+		case nil:
+		case float64:
+			values = append(values, t)
+		default:
+			return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "array of numbers"}
+		}
+	}
+	return values, nil
+}
+
+// sum returns the total of the numbers in an array, 0 when empty, or an error if it overflows.
+func sum(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	values, err := numericElements(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return finite(total)
+}
+
+// avg returns the mean of the numbers in an array, null when empty, or an error if their total overflows.
+func avg(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	values, err := numericElements(args)
+	if err != nil {
+		return nil, err
+	} else if len(values) == 0 {
+		return nil, nil
+	}
+
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return finite(total / float64(len(values)))
+}
+
+// count returns the number of elements in an array that are not null.
+func count(args []any) (any, error) {
+	if args[0] == nil {
+		return 0.0, nil
+	}
+
+	arr, err := arrayArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var n float64
+	for _, v := range arr {
+		if v != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// extreme returns a function returning the smallest, or largest, of the numbers, strings or datetimes
+// in an array, or of its arguments when given more than one, skipping nulls and null when there are none.
+func extreme(largest bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if len(args) > 1 {
+			return extremeOf(args, largest, func(i int) error {
+				return ErrInvalidArgument{Index: i, Value: args[i], Expected: "number, string or datetime like the other arguments"}
+			})
+		} else if args[0] == nil {
+			return nil, nil
+		}
+
+		arr, err := arrayArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return extremeOf(arr, largest, func(int) error {
+			return ErrInvalidArgument{Index: 0, Value: args[0], Expected: "array of numbers, strings or datetimes"}
+		})
+	}
+}
+
+// extremeOf returns the smallest, or largest, of the values, reporting the index of any that cannot be compared.
+func extremeOf(values []any, largest bool, invalid func(i int) error) (any, error) {
+	var result any
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		if result == nil {
+			if _, ok := compareValues(v, v); !ok {
+				return nil, invalid(i)
+			}
+			result = v
+			continue
+		}
+
+		c, ok := compareValues(v, result)
+		if !ok {
+			return nil, invalid(i)
+		}
+		if (largest && c > 0) || (!largest && c < 0) {
+			result = v
+		}
+	}
+	return result, nil
+}
+
+// compareValues orders two numbers, strings or datetimes, reporting false if they are not of the same orderable type.
+func compareValues(a, b any) (int, bool) {
+	switch l := a.(type) {
+	case float64:
+		r, ok := b.(float64)
+		switch {
+		case !ok:
+			return 0, false
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		r, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(l, r), true
+	case time.Time:
+		r, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return l.Compare(r), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,167 @@
+package express
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregates(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "sum",
+			exp:      `SUM(.a)`,
+			src:      `{"a":[1,2,3.5]}`,
+			expected: 6.5,
+		},
+		{
+			name:     "sum gjson path",
+			exp:      `SUM(.items.#.price) > 10`,
+			src:      `{"items":[{"price":4},{"price":7}]}`,
+			expected: true,
+		},
+		{
+			name:     "sum skips nulls",
+			exp:      `SUM(.a)`,
+			src:      `{"a":[1,null,2]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "sum empty",
+			exp:      `SUM(.a)`,
+			src:      `{"a":[]}`,
+			expected: 0.0,
+		},
+		{
+			name:     "sum missing",
+			exp:      `SUM(.a)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "sum array literal",
+			exp:      `SUM([.a, .b, 1])`,
+			src:      `{"a":2,"b":3}`,
+			expected: 6.0,
+		},
+		{
+			name: "sum non-numeric",
+			exp:  `SUM(.a)`,
+			src:  `{"a":[1,"2"]}`,
+			err:  ErrInvalidArgument{Function: "SUM", Index: 0, Value: []any{1.0, "2"}, Expected: "array of numbers", Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "sum non-numeric lenient",
+			exp:      `SUM(.a)`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"a":[1,"2"]}`,
+			expected: nil,
+		},
+		{
+			name: "sum not array",
+			exp:  `SUM(.a)`,
+			src:  `{"a":1}`,
+			err:  ErrInvalidArgument{Function: "SUM", Index: 0, Value: 1.0, Expected: "array", Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name: "sum overflow",
+			exp:  `SUM(.a)`,
+			src:  `{"a":[1e308,1e308]}`,
+			err:  ErrNotFinite{Function: "SUM", Value: math.Inf(1), Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "sum overflow lenient",
+			exp:      `SUM(.a)`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"a":[1e308,1e308]}`,
+			expected: nil,
+		},
+		{
+			name:     "avg",
+			exp:      `AVG(.a)`,
+			src:      `{"a":[1,2,null,6]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "avg empty",
+			exp:      `AVG(.a)`,
+			src:      `{"a":[null]}`,
+			expected: nil,
+		},
+		{
+			name: "avg overflow",
+			exp:  `AVG(.a)`,
+			src:  `{"a":[-1e308,-1e308]}`,
+			err:  ErrNotFinite{Function: "AVG", Value: math.Inf(-1), Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "count",
+			exp:      `COUNT(.a)`,
+			src:      `{"a":[1,null,"x",{}]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "count missing",
+			exp:      `COUNT(.a)`,
+			src:      `{}`,
+			expected: 0.0,
+		},
+		{
+			name: "count not array",
+			exp:  `COUNT(.a)`,
+			src:  `{"a":"x"}`,
+			err:  ErrInvalidArgument{Function: "COUNT", Index: 0, Value: "x", Expected: "array", Span: Span{Start: 0, End: 9}},
+		},
+		{
+			name:     "min",
+			exp:      `MIN(.a)`,
+			src:      `{"a":[3,null,-1,2]}`,
+			expected: -1.0,
+		},
+		{
+			name:     "max",
+			exp:      `MAX(.a)`,
+			src:      `{"a":[3,null,-1,2]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "min strings",
+			exp:      `MIN(.a)`,
+			src:      `{"a":["b","a","c"]}`,
+			expected: "a",
+		},
+		{
+			name:     "max empty",
+			exp:      `MAX(.a)`,
+			src:      `{"a":[]}`,
+			expected: nil,
+		},
+		{
+			name:     "max datetimes",
+			exp:      `MAX([(COERCE .a _datetime_), (COERCE .b _datetime_)]) == COERCE .b _datetime_`,
+			src:      `{"a":"2024-01-02","b":"2024-03-04"}`,
+			expected: true,
+		},
+		{
+			name: "min mixed",
+			exp:  `MIN(.a)`,
+			src:  `{"a":[1,"a"]}`,
+			err:  ErrInvalidArgument{Function: "MIN", Index: 0, Value: []any{1.0, "a"}, Expected: "array of numbers, strings or datetimes", Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "max objects",
+			exp:      `MAX(.a)`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"a":[{}]}`,
+			expected: nil,
+		},
+	})
+}
+
+func TestAggregateConstants(t *testing.T) {
+	assert := require.New(t)
+	ex, err := Parse([]byte(`SUM([1, 2, 3]) == 6`))
+	assert.NoError(err)
+	assert.Equal(`6 == 6`, format(ex))
+}
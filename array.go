@@ -0,0 +1,247 @@
+package express
+
+import (
+	"slices"
+
+	"github.com/pchchv/extender/optionext"
+)
+
+var (
+	_ Expression = (*slice)(nil)
+	_ Expression = (*index)(nil)
+)
+
+// slice is a `[start:end]` slice of an array or string, negative indexes counting back from its end.
+type slice struct {
+	value Expression
+	start optionext.Option[int]
+	end   optionext.Option[int]
+	span  Span
+	opts  ParseOptions
+}
+
+func (s slice) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(s)
+}
+
+func (s slice) eval(env *environment) (any, error) {
+	value, err := env.eval(s.value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		start, end := s.bounds(len(v))
+		return v[start:end:end], nil
+	case string:
+		runes := []rune(v)
+		start, end := s.bounds(len(runes))
+		return string(runes[start:end]), nil
+	default:
+		return s.opts.mismatch(nil, newUnaryTypeError(s.span, "[:]", value))
+	}
+}
+
+// bounds resolves the start and end indexes for a length, clamping them within it.
+func (s slice) bounds(n int) (start, end int) {
+	resolve := func(index optionext.Option[int], fallback int) int {
+		if index.IsNone() {
+			return fallback
+		}
+
+		i := index.Unwrap()
+		if i < 0 {
+			i += n
+		}
+		return min(max(i, 0), n)
+	}
+
+	start, end = resolve(s.start, 0), resolve(s.end, n)
+	return min(start, end), end
+}
+
+// index is an `[index]` element of an array or character of a string, negative indexes counting back from its end.
+type index struct {
+	value Expression
+	i     int
+	span  Span
+	opts  ParseOptions
+}
+
+func (x index) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(x)
+}
+
+func (x index) eval(env *environment) (any, error) {
+	value, err := env.eval(x.value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		if i, ok := x.resolve(len(v)); ok {
+			return v[i], nil
+		}
+		return nil, nil
+	case string:
+		runes := []rune(v)
+		if i, ok := x.resolve(len(runes)); ok {
+			return string(runes[i]), nil
+		}
+		return nil, nil
+	default:
+		return x.opts.mismatch(nil, newUnaryTypeError(x.span, "[]", value))
+	}
+}
+
+// resolve returns the index for a length, reporting false when it is out of range.
+func (x index) resolve(n int) (int, bool) {
+	i := x.i
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n
+}
+
+// sortArray returns a sorted copy of an array of numbers, strings or datetimes, nulls sorting last.
+func sortArray(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	arr, err := arrayArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var first any
+	for _, v := range arr {
+		if v == nil {
+			continue
+		} else if first == nil {
+			first = v
+		}
+
+		if _, ok := compareValues(first, v); !ok {
+			return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "array of numbers, strings or datetimes"}
+		}
+	}
+
+	sorted := slices.Clone(arr)
+	slices.SortStableFunc(sorted, func(a, b any) int {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		case b == nil:
+			return -1
+		}
+		c, _ := compareValues(a, b)
+		return c
+	})
+	return sorted, nil
+}
+
+// distinct returns a function returning the elements of an array without repeats, in the order first found,
+// numbers within the tolerance of one already found being repeats.
+func distinct(tolerance float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		arr, err := arrayArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		unique := make([]any, 0, len(arr))
+		seen := set{scalars: make(map[any]struct{}, len(arr)), tolerance: tolerance}
+		for _, v := range arr {
+			if seen.has(v) {
+				continue
+			}
+			seen.add(v)
+			unique = append(unique, v)
+		}
+		return unique, nil
+	}
+}
+
+// flatten returns an array with the elements of any nested arrays in place of them, one level deep.
+func flatten(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	arr, err := arrayArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := make([]any, 0, len(arr))
+	for _, v := range arr {
+		if nested, ok := v.([]any); ok {
+			flat = append(flat, nested...)
+		} else {
+			flat = append(flat, v)
+		}
+	}
+	return flat, nil
+}
+
+// element returns a function returning the first, or last, element of an array, null when empty.
+func element(last bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		arr, err := arrayArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(arr) == 0:
+			return nil, nil
+		case last:
+			return arr[len(arr)-1], nil
+		default:
+			return arr[0], nil
+		}
+	}
+}
+
+// reverse returns a reversed copy of an array, or the characters of a string in reverse.
+func reverse(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		reversed := slices.Clone(v)
+		slices.Reverse(reversed)
+		return reversed, nil
+	case string:
+		runes := []rune(v)
+		slices.Reverse(runes)
+		return string(runes), nil
+	default:
+		return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "string or array"}
+	}
+}
+
+// indexOfElement returns the index of the first element equal to the value within the tolerance,
+// as found by CONTAINS, or -1.
+func indexOfElement(arr []any, value any, tolerance float64) int {
+	return slices.IndexFunc(arr, func(v any) bool {
+		return equal(v, value, tolerance)
+	})
+}
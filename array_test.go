@@ -0,0 +1,501 @@
+package express
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayFunctions(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "sort numbers",
+			exp:      `SORT(.a)`,
+			src:      `{"a":[3,null,1,2]}`,
+			expected: []any{1.0, 2.0, 3.0, nil},
+		},
+		{
+			name:     "sort strings",
+			exp:      `SORT(.a)`,
+			src:      `{"a":["b","c","a"]}`,
+			expected: []any{"a", "b", "c"},
+		},
+		{
+			name:     "sort copies",
+			exp:      `SORT(.a) != .a && .a == .a`,
+			src:      `{"a":[2,1]}`,
+			expected: true,
+		},
+		{
+			name: "sort mixed",
+			exp:  `SORT(.a)`,
+			src:  `{"a":[1,"a"]}`,
+			err:  ErrInvalidArgument{Function: "SORT", Index: 0, Value: []any{1.0, "a"}, Expected: "array of numbers, strings or datetimes", Span: Span{Start: 0, End: 8}},
+		},
+		{
+			name:     "sort missing",
+			exp:      `SORT(.a)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "distinct",
+			exp:      `DISTINCT(.a)`,
+			src:      `{"a":[1,"1",1,{"b":1},{"b":1},null,null]}`,
+			expected: []any{1.0, "1", map[string]any{"b": 1.0}, nil},
+		},
+		{
+			name:     "distinct numbers and arrays",
+			exp:      `DISTINCT(.a)`,
+			src:      `{"a":[2,[1],2.0,[1],[2],"2"]}`,
+			expected: []any{2.0, []any{1.0}, []any{2.0}, "2"},
+		},
+		{
+			name:     "distinct tolerance",
+			exp:      `DISTINCT(.a)`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":[1,1.001,[2],[2.001],2]}`,
+			expected: []any{1.0, []any{2.0}, 2.0},
+		},
+		{
+			name:     "flatten",
+			exp:      `FLATTEN(.a)`,
+			src:      `{"a":[[1,2],3,[[4]]]}`,
+			expected: []any{1.0, 2.0, 3.0, []any{4.0}},
+		},
+		{
+			name:     "flatten gjson path",
+			exp:      `FLATTEN(.orders.#.items)`,
+			src:      `{"orders":[{"items":["a","b"]},{"items":["c"]}]}`,
+			expected: []any{"a", "b", "c"},
+		},
+		{
+			name:     "first",
+			exp:      `FIRST(.a)`,
+			src:      `{"a":[1,2,3]}`,
+			expected: 1.0,
+		},
+		{
+			name:     "last",
+			exp:      `LAST(.a)`,
+			src:      `{"a":[1,2,3]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "first empty",
+			exp:      `FIRST(.a)`,
+			src:      `{"a":[]}`,
+			expected: nil,
+		},
+		{
+			name: "last not array",
+			exp:  `LAST(.a)`,
+			src:  `{"a":"abc"}`,
+			err:  ErrInvalidArgument{Function: "LAST", Index: 0, Value: "abc", Expected: "array", Span: Span{Start: 0, End: 8}},
+		},
+		{
+			name:     "reverse array",
+			exp:      `REVERSE(.a)`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{3.0, 2.0, 1.0},
+		},
+		{
+			name:     "reverse string",
+			exp:      `REVERSE(.a)`,
+			src:      `{"a":"héllo"}`,
+			expected: "olléh",
+		},
+		{
+			name:     "index of element",
+			exp:      `INDEX_OF(.a, "b")`,
+			src:      `{"a":["a","b"]}`,
+			expected: 1.0,
+		},
+		{
+			name:     "index of object",
+			exp:      `INDEX_OF(.a, .b)`,
+			src:      `{"a":[{"x":1},{"x":2}],"b":{"x":2}}`,
+			expected: 1.0,
+		},
+		{
+			name:     "index of missing element",
+			exp:      `INDEX_OF(.a, 1)`,
+			src:      `{"a":["1"]}`,
+			expected: -1.0,
+		},
+		{
+			name:     "index of tolerance",
+			exp:      `INDEX_OF(.a, 2)`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":[1,1.999]}`,
+			expected: 1.0,
+		},
+		{
+			name:     "index of constant tolerance",
+			exp:      `INDEX_OF([1, 1.999], 2)`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			expected: 1.0,
+		},
+		{
+			name:     "index of substring",
+			exp:      `INDEX_OF(.a, "b")`,
+			src:      `{"a":"abc"}`,
+			expected: 1.0,
+		},
+	})
+}
+
+func TestSlice(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "array",
+			exp:      `.a[1:3]`,
+			src:      `{"a":[1,2,3,4]}`,
+			expected: []any{2.0, 3.0},
+		},
+		{
+			name:     "open start",
+			exp:      `.a[:2]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{1.0, 2.0},
+		},
+		{
+			name:     "open end",
+			exp:      `.a[1:]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{2.0, 3.0},
+		},
+		{
+			name:     "negative",
+			exp:      `.a[-2:]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{2.0, 3.0},
+		},
+		{
+			name:     "negative end",
+			exp:      `.a[:-1]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{1.0, 2.0},
+		},
+		{
+			name:     "out of range",
+			exp:      `.a[-10:10]`,
+			src:      `{"a":[1,2]}`,
+			expected: []any{1.0, 2.0},
+		},
+		{
+			name:     "crossed",
+			exp:      `.a[2:1]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{},
+		},
+		{
+			name:     "string",
+			exp:      `.a[1:-1]`,
+			src:      `{"a":"héllo"}`,
+			expected: "éll",
+		},
+		{
+			name:     "missing",
+			exp:      `.a[1:]`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "function result",
+			exp:      `SORT(.a)[-2:] == [2, 3]`,
+			src:      `{"a":[3,1,2]}`,
+			expected: true,
+		},
+		{
+			name:     "parenthesized",
+			exp:      `(.a)[0:1]`,
+			src:      `{"a":[1,2]}`,
+			expected: []any{1.0},
+		},
+		{
+			name:     "chained",
+			exp:      `.a[1:][1:]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: []any{3.0},
+		},
+		{
+			name:     "operand",
+			exp:      `.a[0:1] CONTAINS 1`,
+			src:      `{"a":[1,2]}`,
+			expected: true,
+		},
+		{
+			name:     "array element",
+			exp:      `[.a[0:1], 2]`,
+			src:      `{"a":[1,2]}`,
+			expected: []any{[]any{1.0}, 2.0},
+		},
+		{
+			name:     "constant",
+			exp:      `[1, 2, 3][1:]`,
+			expected: []any{2.0, 3.0},
+		},
+		{
+			name:     "coercion",
+			exp:      `(COERCE .a _uppercase_)[0:1]`,
+			src:      `{"a":"abc"}`,
+			expected: "A",
+		},
+		{
+			name: "number",
+			exp:  `.a[0:1]`,
+			src:  `{"a":1}`,
+			err:  ErrUnsupportedTypeComparison{Op: "[:]", Right: 1.0, RightType: "number", Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "missing index",
+			exp:      `.a[]`,
+			parseErr: errors.New("expected index or ':' but found `]`"),
+		},
+		{
+			name:     "missing bracket",
+			exp:      `.a[1:2,`,
+			parseErr: errors.New("expected ']' in slice but found `,`"),
+		},
+		{
+			name:     "unclosed",
+			exp:      `.a[1:`,
+			parseErr: errors.New("unclosed subscript '['"),
+		},
+		{
+			name:     "invalid index",
+			exp:      `.a[1.5:]`,
+			parseErr: errors.New("invalid index `1.5`"),
+		},
+	})
+}
+
+func TestSliceFormat(t *testing.T) {
+	assert := require.New(t)
+	tests := []struct {
+		exp       string
+		formatted string
+	}{
+		{exp: `.a[1:3]`},
+		{exp: `.a[:2]`},
+		{exp: `.a[1:]`},
+		{exp: `.a[-2:]`},
+		{exp: `.a[:-1]`},
+		{exp: `.a[-10:10]`},
+		{exp: `.a[2:1]`},
+		{exp: `.a[1:-1]`},
+		{exp: `SORT(.a)[-2:] == [2, 3]`},
+		{exp: `(.a)[0:1]`, formatted: `.a[0:1]`},
+		{exp: `.a[1:][1:]`},
+		{exp: `.a[0:1] CONTAINS 1`},
+		{exp: `[.a[0:1], 2]`},
+		{exp: `[1, 2, 3][1:]`, formatted: `[2,3]`},
+		{exp: `(COERCE .a _uppercase_)[0:1]`},
+		{exp: `.a[0:1]`},
+	}
+
+	for _, tc := range tests {
+		ex, err := Parse([]byte(tc.exp))
+		assert.NoError(err)
+
+		formatted := tc.formatted
+		if formatted == "" {
+			formatted = tc.exp
+		}
+		assert.Equal(formatted, format(ex))
+	}
+}
+
+func TestPostfix(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "index",
+			exp:      `.a[1]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: 2.0,
+		},
+		{
+			name:     "negative index",
+			exp:      `.a[-1]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: 3.0,
+		},
+		{
+			name:     "out of range",
+			exp:      `.a[3]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: nil,
+		},
+		{
+			name:     "negative out of range",
+			exp:      `.a[-4]`,
+			src:      `{"a":[1,2,3]}`,
+			expected: nil,
+		},
+		{
+			name:     "string index",
+			exp:      `.a[1]`,
+			src:      `{"a":"héllo"}`,
+			expected: "é",
+		},
+		{
+			name:     "missing",
+			exp:      `.a[0]`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "function result",
+			exp:      `SPLIT(.csv, ",")[1]`,
+			src:      `{"csv":"a,b,c"}`,
+			expected: "b",
+		},
+		{
+			name:     "parenthesized member",
+			exp:      `(.items)[-1].id`,
+			src:      `{"items":[{"id":1},{"id":2}]}`,
+			expected: 2.0,
+		},
+		{
+			name:     "nested member",
+			exp:      `.items[0].owner.name`,
+			src:      `{"items":[{"owner":{"name":"x"}}]}`,
+			expected: "x",
+		},
+		{
+			name:     "member index",
+			exp:      `FIRST(.items).tags.1`,
+			src:      `{"items":[{"tags":["a","b"]}]}`,
+			expected: "b",
+		},
+		{
+			name:     "missing member",
+			exp:      `FIRST(.items).id`,
+			src:      `{"items":[{}]}`,
+			expected: nil,
+		},
+		{
+			name:     "member of null",
+			exp:      `FIRST(.items).id`,
+			src:      `{"items":[]}`,
+			expected: nil,
+		},
+		{
+			name: "member of number",
+			exp:  `(.a).b`,
+			src:  `{"a":1}`,
+			err:  ErrUnsupportedTypeComparison{Op: ".", Right: 1.0, RightType: "number", Span: Span{Start: 0, End: 6}},
+		},
+		{
+			name:     "coercion",
+			exp:      `(COERCE .a _uppercase_)[0] == "A"`,
+			src:      `{"a":"abc"}`,
+			expected: true,
+		},
+		{
+			name:     "operand",
+			exp:      `.a[0] + 1`,
+			src:      `{"a":[1]}`,
+			expected: 2.0,
+		},
+		{
+			name:     "constant",
+			exp:      `[1, 2, 3][-1]`,
+			expected: 3.0,
+		},
+		{
+			name: "index of number",
+			exp:  `.a[0]`,
+			src:  `{"a":1}`,
+			err:  ErrUnsupportedTypeComparison{Op: "[]", Right: 1.0, RightType: "number", Span: Span{Start: 0, End: 5}},
+		},
+	})
+}
+
+func TestPostfixFormat(t *testing.T) {
+	assert := require.New(t)
+	tests := []struct {
+		exp       string
+		formatted string
+	}{
+		{exp: `.a[1]`},
+		{exp: `.a[-1]`},
+		{exp: `.a[3]`},
+		{exp: `.a[-4]`},
+		{exp: `.a[0]`},
+		{exp: `SPLIT(.csv, ",")[1]`},
+		{exp: `(.items)[-1].id`, formatted: `.items[-1].id`},
+		{exp: `.items[0].owner.name`},
+		{exp: `FIRST(.items).tags.1`},
+		{exp: `FIRST(.items).id`},
+		{exp: `(.a).b`, formatted: `.a.b`},
+		{exp: `(COERCE .a _uppercase_)[0] == "A"`},
+		{exp: `.a[0] + 1`},
+		{exp: `[1, 2, 3][-1]`, formatted: `3`},
+	}
+
+	for _, tc := range tests {
+		ex, err := Parse([]byte(tc.exp))
+		assert.NoError(err)
+
+		formatted := tc.formatted
+		if formatted == "" {
+			formatted = tc.exp
+		}
+		assert.Equal(formatted, format(ex))
+	}
+}
+
+func TestPostfixWhitespace(t *testing.T) {
+	assert := require.New(t)
+	_, err := Parse([]byte(`(.items)[-1] .id`))
+	assert.EqualError(err, "invalid operation: `.id`, a member access must follow its value without whitespace at line 1, column 14")
+
+	// whitespace separates the operands of BETWEEN instead
+	ex, err := Parse([]byte(`.a BETWEEN (.b) .c`))
+	assert.NoError(err)
+	got, err := ex.Calculate([]byte(`{"a":2,"b":1,"c":3}`))
+	assert.NoError(err)
+	assert.Equal(true, got)
+
+	// lenient calculates the member of a value other than an object or array as null
+	ex, err = ParseWithOptions([]byte(`(.a).b`), ParseOptions{Mode: Lenient})
+	assert.NoError(err)
+	got, err = ex.Calculate([]byte(`{"a":1}`))
+	assert.NoError(err)
+	assert.Nil(got)
+}
+
+func TestSelectorBracket(t *testing.T) {
+	assert := require.New(t)
+	src := []byte(`{"a":[1,2],"a[0":"key"}`)
+
+	// a `[` now ends the selector path and indexes its value, where previously `.a[0]` looked up the key "a[0"
+	ex, err := Parse([]byte(`.a[0]`))
+	assert.NoError(err)
+	got, err := ex.Calculate(src)
+	assert.NoError(err)
+	assert.Equal(1.0, got)
+
+	// a gjson multipath keeps its commas, while others end the selector path unless escaped
+	ex, err = Parse([]byte(`.{a,b}`))
+	assert.NoError(err)
+	got, err = ex.Calculate([]byte(`{"a":1,"b":2}`))
+	assert.NoError(err)
+	assert.Equal(map[string]any{"a": 1.0, "b": 2.0}, got)
+
+	ex, err = Parse([]byte(`JOIN([.a\,b, .c], "-")`))
+	assert.NoError(err)
+	got, err = ex.Calculate([]byte(`{"a,b":"x","c":"y"}`))
+	assert.NoError(err)
+	assert.Equal("x-y", got)
+
+	// escaping the bracket keeps it within the gjson path
+	ex, err = Parse([]byte(`.a\[0`))
+	assert.NoError(err)
+	got, err = ex.Calculate(src)
+	assert.NoError(err)
+	assert.Equal("key", got)
+}
@@ -167,3 +167,51 @@ func benchExecution(b *testing.B, expression, input string) {
 		}
 	}
 }
+
+func BenchmarkExecutionStructSource(b *testing.B) {
+	ex, err := Parse([]byte(`.properties.employees > 20`))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	type properties struct {
+		Employees int `json:"employees"`
+	}
+	src := StructSource(struct {
+		Name       string     `json:"name"`
+		Properties properties `json:"properties"`
+	}{Name: "Company", Properties: properties{Employees: 50}})
+	for i := 0; i < b.N; i++ {
+		if _, err := CalculateSource(ex, src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExecutionDocument(b *testing.B) {
+	expressions := []string{
+		`.properties.employees > 20`,
+		`.properties.employees < 100 && .name == "Company"`,
+		`.name STARTSWITH "Comp" || .properties.employees == 0`,
+	}
+
+	exs := make([]Expression, 0, len(expressions))
+	for _, expression := range expressions {
+		ex, err := Parse([]byte(expression))
+		if err != nil {
+			b.Fatal(err)
+		}
+		exs = append(exs, ex)
+	}
+
+	in := []byte(`{"name":"Company","properties":{"employees":50}}`)
+	b.SetBytes(int64(len(in)))
+	for i := 0; i < b.N; i++ {
+		doc := NewDocument(in)
+		for _, ex := range exs {
+			if _, err := CalculateDoc(ex, doc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
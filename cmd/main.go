@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -21,9 +22,18 @@ func isInputFromPipe() bool {
 	return fileInfo.Mode()&os.ModeCharDevice == 0
 }
 
+// writeExplain writes the trace of applying the expression to the input,
+// evaluation errors are included in the trace.
+func writeExplain(w *bufio.Writer, ex express.Expression, input []byte) error {
+	trace, _ := express.Explain(ex, input)
+	_, err := w.WriteString(trace.String())
+	return err
+}
+
 func main() {
-	var outputOriginal bool
+	var outputOriginal, explain bool
 	flag.BoolVar(&outputOriginal, "o", false, "Indicates if the original data will be output after applying the expression. The results of the expression MUST be a boolean otherwise the output will be ignored.")
+	flag.BoolVar(&explain, "explain", false, "Indicates if the evaluation of each part of the expression will be output as indented text instead of the result.")
 	flag.Usage = usage
 	flag.Parse()
 
@@ -35,7 +45,11 @@ func main() {
 
 	ex, err := express.Parse([]byte(flag.Arg(0)))
 	if err != nil {
-		flag.Usage()
+		fmt.Fprintln(os.Stderr, "parsing expression:", err)
+		var parseErr express.ParseError
+		if errors.As(err, &parseErr) {
+			fmt.Fprintln(os.Stderr, parseErr.Snippet())
+		}
 		return
 	}
 
@@ -44,7 +58,14 @@ func main() {
 	if isPipe {
 		scanner := bufio.NewScanner(os.Stdin)
 		scanner.Buffer(make([]byte, 0, 200*bytesext.KiB), 5*bytesext.MiB)
-		if outputOriginal {
+		if explain {
+			for scanner.Scan() {
+				if err := writeExplain(w, ex, scanner.Bytes()); err != nil {
+					fmt.Fprintln(os.Stderr, "writing standard output:", err)
+					return
+				}
+			}
+		} else if outputOriginal {
 			for scanner.Scan() {
 				input := scanner.Bytes()
 				result, err := ex.Calculate(input)
@@ -88,6 +109,17 @@ func main() {
 		}
 	} else {
 		input = []byte(flag.Arg(1))
+		if explain {
+			if err := writeExplain(w, ex, input); err != nil {
+				fmt.Fprintln(os.Stderr, "writing standard output:", err)
+			}
+
+			if err = w.Flush(); err != nil {
+				fmt.Fprintln(os.Stderr, "writing standard output:", err)
+			}
+			return
+		}
+
 		result, err := ex.Calculate(input)
 		if err != nil {
 			flag.Usage()
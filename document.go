@@ -0,0 +1,58 @@
+package express
+
+import (
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+var _ Source = (*Document)(nil)
+
+// Document is a Source of raw JSON for evaluating many expressions against the same payload.
+//
+// The value of each selector path is looked up once and cached for all subsequent expressions
+// rather than rescanning the raw JSON for every selector. It is safe for concurrent use.
+type Document struct {
+	raw   []byte
+	m     sync.Mutex
+	cache map[string]documentValue
+}
+
+type documentValue struct {
+	value  any
+	exists bool
+}
+
+// NewDocument creates a new Document for the supplied JSON.
+func NewDocument(src []byte) *Document {
+	return &Document{
+		raw:   src,
+		cache: make(map[string]documentValue),
+	}
+}
+
+// Get returns the value found at the gjson path.
+//
+// The returned value is shared between all callers and must not be modified.
+func (d *Document) Get(path string) (any, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	v, found := d.cache[path]
+	if !found {
+		result := gjson.GetBytes(d.raw, path)
+		v = documentValue{value: result.Value(), exists: result.Exists()}
+		d.cache[path] = v
+	}
+	return v.value, v.exists
+}
+
+// Bytes returns the raw JSON of the Document.
+func (d *Document) Bytes() []byte {
+	return d.raw
+}
+
+// CalculateDoc executes the parsed expression against the Document.
+func CalculateDoc(e Expression, doc *Document) (any, error) {
+	return CalculateSource(e, doc)
+}
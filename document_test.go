@@ -0,0 +1,76 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateDoc(t *testing.T) {
+	assert := require.New(t)
+	doc := NewDocument([]byte(`{"name":"MyCompany","properties":{"employees":50,"tags":["a","b"]},"deleted":null}`))
+	tests := []struct {
+		name     string
+		exp      string
+		expected any
+	}{
+		{
+			name:     "selector",
+			exp:      `.properties.employees > 20`,
+			expected: true,
+		},
+		{
+			name:     "same selector",
+			exp:      `.properties.employees + 1`,
+			expected: 51.0,
+		},
+		{
+			name:     "gjson syntax",
+			exp:      `.properties.tags.#`,
+			expected: 2.0,
+		},
+		{
+			name:     "array",
+			exp:      `"b" IN .properties.tags`,
+			expected: true,
+		},
+		{
+			name:     "missing",
+			exp:      `.missing == NULL && .deleted == NULL`,
+			expected: true,
+		},
+		{
+			name:     "custom expression",
+			exp:      `COERCE .name _star_`,
+			expected: "*********",
+		},
+	}
+
+	guard := Coercions.Lock()
+	guard.T["_star_"] = func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		return constEligible, &Star{expression}, nil
+	}
+	guard.Unlock()
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			got, err := CalculateDoc(ex, doc)
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+
+	value, exists := doc.Get("deleted")
+	assert.True(exists)
+	assert.Nil(value)
+
+	value, exists = doc.Get("missing")
+	assert.False(exists)
+	assert.Nil(value)
+}
@@ -0,0 +1,246 @@
+package express
+
+import (
+	"strings"
+	"time"
+)
+
+// ToElasticsearch transpiles a parsed Expression into an Elasticsearch bool query.
+//
+// Comparisons must be between a selector and a constant, selectors are mapped to dotted field paths,
+// equality to `term`, ordering to `range`, STARTSWITH to `prefix` and CONTAINS or ENDSWITH on strings to `wildcard` queries.
+// DateTime constants are formatted as RFC3339 strings and null to a `must_not` `exists` query,
+// while comparisons with an array or object constant are unsupported.
+// EXISTS is mapped to an `exists` query, which as Elasticsearch does not index null values is false for a null field.
+//
+// Will return ErrUnsupportedTranspile for constructs that have no Elasticsearch equivalent.
+func ToElasticsearch(e Expression) (map[string]any, error) {
+	switch t := e.(type) {
+	case and:
+		return esBool("filter", t)
+	case or:
+		return esBool("should", t)
+	case not:
+		inner, err := ToElasticsearch(t.value)
+		if err != nil {
+			return nil, err
+		}
+		return esMustNot(inner), nil
+	case selectorPath:
+		field, ok := fieldPath(t)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "selector path ." + t.s}
+		}
+		return map[string]any{"term": map[string]any{field: true}}, nil
+	case exists:
+		field, ok := fieldPath(selectorPath{s: t.s})
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "selector path ." + t.s}
+		}
+		return map[string]any{"exists": map[string]any{"field": field}}, nil
+	case eq:
+		field, value, _, ok := fieldOperands(t.left, t.right)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison between a non selector and non constant"}
+		}
+
+		return esTerm(field, value)
+	case gt:
+		return esRange("gt", t.left, t.right)
+	case gte:
+		return esRange("gte", t.left, t.right)
+	case lt:
+		return esRange("lt", t.left, t.right)
+	case lte:
+		return esRange("lte", t.left, t.right)
+	case between:
+		field, ok := fieldPath(t.value)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "BETWEEN on a non selector value"}
+		}
+
+		lower, lok := literalValue(t.left)
+		upper, uok := literalValue(t.right)
+		if !lok || !uok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "BETWEEN with non constant bounds"}
+		}
+
+		// express BETWEEN is exclusive of both bounds
+		return map[string]any{"range": map[string]any{field: map[string]any{"gt": esValue(lower), "lt": esValue(upper)}}}, nil
+	case in:
+		field, value, flipped, ok := fieldOperands(t.left, t.right)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "IN between a non selector and non constant"}
+		}
+
+		// Elasticsearch matches a term against every element of an array field
+		if flipped {
+			return esTerm(field, value)
+		}
+
+		arr, ok := value.([]any)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "IN with a non array value"}
+		}
+
+		for _, v := range arr {
+			switch v.(type) {
+			case nil, []any, map[string]any:
+				return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "IN with null, array or object elements"}
+			}
+		}
+		return map[string]any{"terms": map[string]any{field: esValue(value)}}, nil
+	case contains:
+		return esContains(t.left, t.right)
+	case containsAny:
+		return esContainsEach("should", "CONTAINS_ANY", t.left, t.right)
+	case containsAll:
+		return esContainsEach("filter", "CONTAINS_ALL", t.left, t.right)
+	case startsWith:
+		field, s, err := esStringOperands("STARTSWITH", t.left, t.right)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"prefix": map[string]any{field: map[string]any{"value": s}}}, nil
+	case endsWith:
+		field, s, err := esStringOperands("ENDSWITH", t.left, t.right)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"wildcard": map[string]any{field: map[string]any{"value": "*" + escapeWildcard(s)}}}, nil
+	default:
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: format(e)}
+	}
+}
+
+// esBool flattens nested `&&` or `||` expressions into a single bool query.
+func esBool(occur string, e Expression) (map[string]any, error) {
+	operands := flattenLogical(e)
+	clauses := make([]any, 0, len(operands))
+	for _, operand := range operands {
+		query, err := ToElasticsearch(operand)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, query)
+	}
+
+	query := map[string]any{occur: clauses}
+	if occur == "should" {
+		query["minimum_should_match"] = 1
+	}
+	return map[string]any{"bool": query}, nil
+}
+
+func esMustNot(query map[string]any) map[string]any {
+	return map[string]any{"bool": map[string]any{"must_not": []any{query}}}
+}
+
+func esRange(op string, left, right Expression) (map[string]any, error) {
+	field, value, flipped, ok := fieldOperands(left, right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison between a non selector and non constant"}
+	}
+
+	if flipped {
+		op = flippedRangeOps[op]
+	}
+	return map[string]any{"range": map[string]any{field: map[string]any{op: esValue(value)}}}, nil
+}
+
+var flippedRangeOps = map[string]string{
+	"gt":  "lt",
+	"gte": "lte",
+	"lt":  "gt",
+	"lte": "gte",
+}
+
+func esContains(left, right Expression) (map[string]any, error) {
+	field, ok := fieldPath(left)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "CONTAINS on a non selector value"}
+	}
+
+	value, ok := literalValue(right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "CONTAINS with a non constant value"}
+	}
+
+	if s, ok := value.(string); ok {
+		return map[string]any{"wildcard": map[string]any{field: map[string]any{"value": "*" + escapeWildcard(s) + "*"}}}, nil
+	}
+	return esTerm(field, value)
+}
+
+// esTerm matches the field against a single value, null matching a missing or null field.
+func esTerm(field string, value any) (map[string]any, error) {
+	switch value.(type) {
+	case nil:
+		return esMustNot(map[string]any{"exists": map[string]any{"field": field}}), nil
+	case []any, map[string]any:
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison with an array or object value"}
+	default:
+		return map[string]any{"term": map[string]any{field: esValue(value)}}, nil
+	}
+}
+
+func esContainsEach(occur, name string, left, right Expression) (map[string]any, error) {
+	value, ok := literalValue(right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: name + " with a non constant value"}
+	}
+
+	arr, ok := value.([]any)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "Elasticsearch", s: name + " with a non array value"}
+	}
+
+	clauses := make([]any, 0, len(arr))
+	for _, v := range arr {
+		query, err := esContains(left, coercedConstant{value: v})
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, query)
+	}
+
+	query := map[string]any{occur: clauses}
+	if occur == "should" {
+		query["minimum_should_match"] = 1
+	}
+	return map[string]any{"bool": query}, nil
+}
+
+func esStringOperands(name string, left, right Expression) (string, string, error) {
+	field, ok := fieldPath(left)
+	if !ok {
+		return "", "", ErrUnsupportedTranspile{target: "Elasticsearch", s: name + " on a non selector value"}
+	}
+
+	value, _ := literalValue(right)
+	s, ok := value.(string)
+	if !ok {
+		return "", "", ErrUnsupportedTranspile{target: "Elasticsearch", s: name + " with a non string constant"}
+	}
+	return field, s, nil
+}
+
+// esValue converts a constant into its Elasticsearch JSON representation.
+func esValue(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []any:
+		arr := make([]any, 0, len(v))
+		for _, e := range v {
+			arr = append(arr, esValue(e))
+		}
+		return arr
+	default:
+		return value
+	}
+}
+
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
@@ -0,0 +1,149 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToElasticsearch(t *testing.T) {
+	assert := require.New(t)
+	tests := []struct {
+		name     string
+		exp      string
+		expected map[string]any
+		err      error
+	}{
+		{
+			name:     "eq",
+			exp:      `.a.b == "x"`,
+			expected: map[string]any{"term": map[string]any{"a.b": "x"}},
+		},
+		{
+			name: "eq null",
+			exp:  `.a == NULL`,
+			expected: map[string]any{"bool": map[string]any{"must_not": []any{
+				map[string]any{"exists": map[string]any{"field": "a"}},
+			}}},
+		},
+		{
+			name:     "exists",
+			exp:      `EXISTS .a.b`,
+			expected: map[string]any{"exists": map[string]any{"field": "a.b"}},
+		},
+		{
+			name: "missing",
+			exp:  `.a IS MISSING`,
+			expected: map[string]any{"bool": map[string]any{"must_not": []any{
+				map[string]any{"exists": map[string]any{"field": "a"}},
+			}}},
+		},
+		{
+			name:     "flipped gte",
+			exp:      `20 >= .employees`,
+			expected: map[string]any{"range": map[string]any{"employees": map[string]any{"lte": float64(20)}}},
+		},
+		{
+			name: "and or",
+			exp:  `.region == "eu" && (.type IN ["a", "b"] || .name STARTSWITH "x")`,
+			expected: map[string]any{"bool": map[string]any{"filter": []any{
+				map[string]any{"term": map[string]any{"region": "eu"}},
+				map[string]any{"bool": map[string]any{
+					"should": []any{
+						map[string]any{"terms": map[string]any{"type": []any{"a", "b"}}},
+						map[string]any{"prefix": map[string]any{"name": map[string]any{"value": "x"}}},
+					},
+					"minimum_should_match": 1,
+				}},
+			}}},
+		},
+		{
+			name: "not eq",
+			exp:  `.a != 1`,
+			expected: map[string]any{"bool": map[string]any{"must_not": []any{
+				map[string]any{"term": map[string]any{"a": float64(1)}},
+			}}},
+		},
+		{
+			name:     "contains",
+			exp:      `.name CONTAINS "a*b"`,
+			expected: map[string]any{"wildcard": map[string]any{"name": map[string]any{"value": `*a\*b*`}}},
+		},
+		{
+			name:     "endswith",
+			exp:      `.name ENDSWITH "am"`,
+			expected: map[string]any{"wildcard": map[string]any{"name": map[string]any{"value": "*am"}}},
+		},
+		{
+			name: "between datetime",
+			exp:  `COERCE .created _datetime_ BETWEEN COERCE "2022-01-01" _datetime_ COERCE "2022-01-30" _datetime_`,
+			expected: map[string]any{"range": map[string]any{"created": map[string]any{
+				"gt": "2022-01-01T00:00:00Z",
+				"lt": "2022-01-30T00:00:00Z",
+			}}},
+		},
+		{
+			name: "contains all",
+			exp:  `.tags CONTAINS_ALL [1, 2]`,
+			expected: map[string]any{"bool": map[string]any{"filter": []any{
+				map[string]any{"term": map[string]any{"tags": float64(1)}},
+				map[string]any{"term": map[string]any{"tags": float64(2)}},
+			}}},
+		},
+		{
+			name: "null in array field",
+			exp:  `NULL IN .tags`,
+			expected: map[string]any{"bool": map[string]any{"must_not": []any{
+				map[string]any{"exists": map[string]any{"field": "tags"}},
+			}}},
+		},
+		{
+			name: "eq array",
+			exp:  `.a == [1, 2]`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison with an array or object value"},
+		},
+		{
+			name: "array in array field",
+			exp:  `[1] IN .a`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison with an array or object value"},
+		},
+		{
+			name: "in with null element",
+			exp:  `.a IN [1, NULL]`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "IN with null, array or object elements"},
+		},
+		{
+			name: "selector compared to selector",
+			exp:  `.a > .b`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison between a non selector and non constant"},
+		},
+		{
+			name: "function",
+			exp:  `LEN(.a)`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "LEN(.a)"},
+		},
+		{
+			name: "coercion",
+			exp:  `COERCE .a _uppercase_ == "A"`,
+			err:  ErrUnsupportedTranspile{target: "Elasticsearch", s: "comparison between a non selector and non constant"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			got, err := ToElasticsearch(ex)
+			if tc.err != nil {
+				assert.Equal(tc.err, err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
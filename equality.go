@@ -0,0 +1,105 @@
+package express
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// equal reports whether two values are equal, the equality used by `==`, `!=`, IN, CONTAINS, CONTAINS_ANY,
+// CONTAINS_ALL, the set operators and the DISTINCT and INDEX_OF functions.
+//
+// Values of different types are never equal, a datetime never being equal to a string. Numbers of any Go type
+// are equal when within the tolerance of each other, datetimes when they are the same instant in any
+// time zone and arrays and objects when they hold equal elements.
+func equal(a, b any, tolerance float64) bool {
+	if l, ok := number(a); ok {
+		r, ok := number(b)
+		return ok && (l == r || math.Abs(l-r) <= tolerance)
+	}
+
+	switch l := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		r, ok := b.(string)
+		return ok && l == r
+	case bool:
+		r, ok := b.(bool)
+		return ok && l == r
+	case time.Time:
+		r, ok := b.(time.Time)
+		return ok && l.Equal(r)
+	case []any:
+		r, ok := b.([]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+
+		for i := range l {
+			if !equal(l[i], r[i], tolerance) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		r, ok := b.(map[string]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+
+		for k, v := range l {
+			if rv, found := r[k]; !found || !equal(v, rv, tolerance) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// number returns the value as a float64 when it is a number of any Go type.
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case nil:
+		return 0, false
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// instant is the key of a datetime, being equal for the same instant in any time zone.
+type instant struct {
+	sec  int64
+	nsec int
+}
+
+// equalityKey returns a map key for the value, values being equal with no tolerance having equal keys,
+// reporting false for arrays, objects and any other values that cannot be hashed.
+func equalityKey(value any) (any, bool) {
+	if f, ok := number(value); ok {
+		return f, true
+	}
+
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, true
+	case time.Time:
+		return instant{sec: v.Unix(), nsec: v.Nanosecond()}, true
+	default:
+		return nil, false
+	}
+}
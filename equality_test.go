@@ -0,0 +1,227 @@
+package express
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	instant := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		a, b      any
+		tolerance float64
+		expected  bool
+	}{
+		{
+			name:     "numbers",
+			a:        1.0,
+			b:        1.0,
+			expected: true,
+		},
+		{
+			name:     "different numbers",
+			a:        1.0,
+			b:        1.1,
+			expected: false,
+		},
+		{
+			name:      "within tolerance",
+			a:         1.0,
+			b:         1.05,
+			tolerance: 0.1,
+			expected:  true,
+		},
+		{
+			name:      "outside tolerance",
+			a:         1.0,
+			b:         1.5,
+			tolerance: 0.1,
+			expected:  false,
+		},
+		{
+			name:     "go numbers",
+			a:        2,
+			b:        uint8(2),
+			expected: true,
+		},
+		{
+			name:     "number and string",
+			a:        1.0,
+			b:        "1",
+			expected: false,
+		},
+		{
+			name:     "nulls",
+			a:        nil,
+			b:        nil,
+			expected: true,
+		},
+		{
+			name:     "null and false",
+			a:        nil,
+			b:        false,
+			expected: false,
+		},
+		{
+			name:     "datetimes in time zones",
+			a:        instant,
+			b:        instant.In(time.FixedZone("X", 3600)),
+			expected: true,
+		},
+		{
+			name:     "datetime and string",
+			a:        instant,
+			b:        "2024-01-02T03:04:05Z",
+			expected: false,
+		},
+		{
+			name:     "arrays",
+			a:        []any{1.0, []any{"a"}},
+			b:        []any{1.0, []any{"a"}},
+			expected: true,
+		},
+		{
+			name:     "array order",
+			a:        []any{1.0, 2.0},
+			b:        []any{2.0, 1.0},
+			expected: false,
+		},
+		{
+			name:     "array lengths",
+			a:        []any{1.0},
+			b:        []any{1.0, 1.0},
+			expected: false,
+		},
+		{
+			name:      "arrays within tolerance",
+			a:         []any{1.0},
+			b:         []any{1.01},
+			tolerance: 0.1,
+			expected:  true,
+		},
+		{
+			name:     "objects",
+			a:        map[string]any{"a": 1.0, "b": nil},
+			b:        map[string]any{"b": nil, "a": 1.0},
+			expected: true,
+		},
+		{
+			name:     "object missing key",
+			a:        map[string]any{"a": nil},
+			b:        map[string]any{"b": nil},
+			expected: false,
+		},
+		{
+			name:     "object and array",
+			a:        map[string]any{},
+			b:        []any{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(tc.expected, equal(tc.a, tc.b, tc.tolerance))
+			assert.Equal(tc.expected, equal(tc.b, tc.a, tc.tolerance))
+		})
+	}
+}
+
+func TestEquality(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "in nested arrays",
+			exp:      `.a IN .b`,
+			src:      `{"a":[1],"b":[[2],[1]]}`,
+			expected: true,
+		},
+		{
+			name:     "in objects",
+			exp:      `.a IN .b`,
+			src:      `{"a":{"x":1},"b":[{"x":2},{"x":1}]}`,
+			expected: true,
+		},
+		{
+			name:     "in mixed",
+			exp:      `.a IN .b`,
+			src:      `{"a":[1],"b":[1,"1",{"x":1}]}`,
+			expected: false,
+		},
+		{
+			name:     "eq objects",
+			exp:      `.a == .b`,
+			src:      `{"a":{"x":[1,2]},"b":{"x":[1,2]}}`,
+			expected: true,
+		},
+		{
+			name:     "eq datetimes",
+			exp:      `COERCE .a _datetime_ == COERCE .b _datetime_`,
+			src:      `{"a":"2024-01-02T03:00:00Z","b":"2024-01-02T04:00:00+01:00"}`,
+			expected: true,
+		},
+		{
+			name:     "eq datetime string",
+			exp:      `COERCE .a _datetime_ == .a`,
+			src:      `{"a":"2024-01-02T03:00:00Z"}`,
+			expected: false,
+		},
+		{
+			name:     "eq tolerance",
+			exp:      `.a + .b == 0.3`,
+			opts:     ParseOptions{Tolerance: 1e-9},
+			src:      `{"a":0.1,"b":0.2}`,
+			expected: true,
+		},
+		{
+			name:     "eq no tolerance",
+			exp:      `.a + .b == 0.3`,
+			src:      `{"a":0.1,"b":0.2}`,
+			expected: false,
+		},
+		{
+			name:     "not eq tolerance",
+			exp:      `.a != 1`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":1.001}`,
+			expected: false,
+		},
+		{
+			name:     "in tolerance",
+			exp:      `.a IN [1, 2]`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":1.999}`,
+			expected: true,
+		},
+		{
+			name:     "contains tolerance",
+			exp:      `.a CONTAINS 2`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":[1.999]}`,
+			expected: true,
+		},
+		{
+			name:     "contains any tolerance",
+			exp:      `.a CONTAINS_ANY [2]`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":[1.999]}`,
+			expected: true,
+		},
+		{
+			name:     "intersects tolerance",
+			exp:      `.a INTERSECTS [2]`,
+			opts:     ParseOptions{Tolerance: 0.01},
+			src:      `{"a":[1.999]}`,
+			expected: true,
+		},
+		{
+			name:     "set equals datetimes",
+			exp:      `[(COERCE .a _datetime_)] SET_EQUALS [(COERCE .b _datetime_)]`,
+			src:      `{"a":"2024-01-02T03:00:00Z","b":"2024-01-02T04:00:00+01:00"}`,
+			expected: true,
+		},
+	})
+}
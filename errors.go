@@ -1,6 +1,91 @@
 package express
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError represents an error parsing an expression, locating where in the expression it occurred.
+type ParseError struct {
+	// Offset is the byte offset of the error within the expression.
+	Offset int
+	// Line is the line of the error, starting at 1.
+	Line int
+	// Column is the column, in characters, of the error within its line starting at 1.
+	Column int
+	// Err is the underlying error.
+	Err error
+	exp []byte
+}
+
+func newParseError(exp []byte, offset int, err error) ParseError {
+	// errors calculating constant expressions when parsed are located by the start of their Span instead
+	if span, unlocated := withoutSpan(err); span != (Span{}) && span.Start <= len(exp) {
+		offset, err = span.Start, unlocated
+	}
+
+	lineStart := bytes.LastIndexByte(exp[:offset], '\n') + 1
+	return ParseError{
+		Offset: offset,
+		Line:   bytes.Count(exp[:offset], []byte{'\n'}) + 1,
+		Column: utf8.RuneCount(exp[lineStart:offset]) + 1,
+		Err:    err,
+		exp:    exp,
+	}
+}
+
+// withoutSpan returns the Span of the error, if it has one, and the error without it.
+func withoutSpan(err error) (Span, error) {
+	var span Span
+	switch e := err.(type) {
+	case ErrUnsupportedTypeComparison:
+		span, e.Span = e.Span, Span{}
+		err = e
+	case ErrUnsupportedCoerce:
+		span, e.Span = e.Span, Span{}
+		err = e
+	case ErrInvalidArgument:
+		span, e.Span = e.Span, Span{}
+		err = e
+	case ErrNotFinite:
+		span, e.Span = e.Span, Span{}
+		err = e
+	case ErrResultTooLong:
+		span, e.Span = e.Span, Span{}
+		err = e
+	}
+	return span, err
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d", e.Err.Error(), e.Line, e.Column)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Snippet renders the line of the expression containing the error with a caret marking the error's position.
+func (e ParseError) Snippet() string {
+	lineStart := bytes.LastIndexByte(e.exp[:e.Offset], '\n') + 1
+	lineEnd := len(e.exp)
+	if i := bytes.IndexByte(e.exp[e.Offset:], '\n'); i >= 0 {
+		lineEnd = e.Offset + i
+	}
+
+	// keep tabs so the caret lines up with the offending character
+	var indent strings.Builder
+	for _, r := range string(e.exp[lineStart:e.Offset]) {
+		if r == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+	return fmt.Sprintf("%s\n%s^", strings.TrimRight(string(e.exp[lineStart:lineEnd]), "\r"), indent.String())
+}
 
 // ErrUnterminatedString represents an unterminated string.
 type ErrUnterminatedString struct {
@@ -75,6 +160,10 @@ func (e ErrInvalidCoerce) Error() string {
 	return fmt.Sprintf("invalid COERCE: `%s`", e.Err.Error())
 }
 
+func (e ErrInvalidCoerce) Unwrap() error {
+	return e.Err
+}
+
 // ErrCustom represents a custom error.
 type ErrCustom struct {
 	S string
@@ -84,20 +173,161 @@ func (e ErrCustom) Error() string {
 	return e.S
 }
 
-// ErrUnsupportedTypeComparison represents a comparison of incompatible types.
+// ErrUnsupportedTypeComparison represents an operator applied to operands of incompatible types.
 type ErrUnsupportedTypeComparison struct {
-	s string
+	// Op is the operator, such as `>` or `CONTAINS`.
+	Op string
+	// Left and Right are the operand values, Left being nil for unary operators such as `!`.
+	Left, Right any
+	// LeftType and RightType are the types of the operands as returned by TYPEOF, such as `number` or `bool`,
+	// LeftType being empty for unary operators.
+	LeftType, RightType string
+	// Span locates the failing subexpression within the parsed expression, being zero when unknown.
+	Span Span
+}
+
+func newTypeError(span Span, op string, left, right any) ErrUnsupportedTypeComparison {
+	return ErrUnsupportedTypeComparison{
+		Op:        op,
+		Left:      left,
+		Right:     right,
+		LeftType:  typeOf(left),
+		RightType: typeOf(right),
+		Span:      span,
+	}
+}
+
+func newUnaryTypeError(span Span, op string, value any) ErrUnsupportedTypeComparison {
+	return ErrUnsupportedTypeComparison{
+		Op:        op,
+		Right:     value,
+		RightType: typeOf(value),
+		Span:      span,
+	}
 }
 
 func (e ErrUnsupportedTypeComparison) Error() string {
-	return fmt.Sprintf("unsupported type comparison: `%s`", e.s)
+	var msg string
+	if e.LeftType == "" {
+		msg = fmt.Sprintf("unsupported type comparison: `%s %s` (%s %s)", e.Op, errorValue(e.Right), e.Op, e.RightType)
+	} else {
+		msg = fmt.Sprintf("unsupported type comparison: `%s %s %s` (%s %s %s)", errorValue(e.Left), e.Op, errorValue(e.Right), e.LeftType, e.Op, e.RightType)
+	}
+	return msg + e.Span.location()
 }
 
 // ErrUnsupportedCoerce represents a comparison of incompatible types type casts.
 type ErrUnsupportedCoerce struct {
 	s string
+	// Span locates the failing COERCE within the parsed expression, being zero when unknown.
+	Span Span
 }
 
 func (e ErrUnsupportedCoerce) Error() string {
-	return fmt.Sprintf("unsupported type comparison for COERCE: `%s`", e.s)
+	return fmt.Sprintf("unsupported type comparison for COERCE: `%s`", e.s) + e.Span.location()
+}
+
+// ErrInvalidArgument represents an argument of an unsupported type or value supplied to a Function.
+type ErrInvalidArgument struct {
+	// Function is the name of the function called.
+	Function string
+	// Index is the position of the argument, starting at 0.
+	Index int
+	// Value is the argument supplied.
+	Value any
+	// Expected describes the argument expected, such as `string`.
+	Expected string
+	// Span locates the failing function call within the parsed expression, being zero when unknown.
+	Span Span
+}
+
+func (e ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("invalid argument %d to %s: expected %s but found `%s` (%s)", e.Index+1, e.Function, e.Expected, errorValue(e.Value), typeOf(e.Value)) + e.Span.location()
+}
+
+// ErrNotFinite represents a Function resulting in NaN or an infinity, such as `SQRT(-1)` or `POW(10, 400)`,
+// which are not valid JSON numbers.
+type ErrNotFinite struct {
+	// Function is the name of the function called.
+	Function string
+	// Value is the NaN or infinite result.
+	Value float64
+	// Span locates the failing function call within the parsed expression, being zero when unknown.
+	Span Span
+}
+
+func (e ErrNotFinite) Error() string {
+	return fmt.Sprintf("%s results in %v, which is not a finite number", e.Function, e.Value) + e.Span.location()
+}
+
+// ErrResultTooLong represents a Function that would produce a string longer than MaxResultLength,
+// such as `REPEAT("abc", 2147483647)`.
+type ErrResultTooLong struct {
+	// Function is the name of the function called.
+	Function string
+	// Length is the length in bytes the result would have been.
+	Length int
+	// Span locates the failing function call within the parsed expression, being zero when unknown.
+	Span Span
+}
+
+func (e ErrResultTooLong) Error() string {
+	return fmt.Sprintf("%s results in %d bytes, exceeding the maximum of %d", e.Function, e.Length, MaxResultLength) + e.Span.location()
+}
+
+// ErrUnsupportedTranspile represents an expression that cannot be transpiled to the target query language.
+type ErrUnsupportedTranspile struct {
+	target string
+	s      string
+}
+
+func (e ErrUnsupportedTranspile) Error() string {
+	return fmt.Sprintf("unsupported expression for %s: `%s`", e.target, e.s)
+}
+
+// ErrRule represents an error evaluating a rule of a RuleSet.
+type ErrRule struct {
+	ID  string
+	Err error
+}
+
+func (e ErrRule) Error() string {
+	return fmt.Sprintf("rule `%s`: %s", e.ID, e.Err.Error())
+}
+
+func (e ErrRule) Unwrap() error {
+	return e.Err
+}
+
+// ErrBudgetExceeded represents an evaluation exceeding one of the Limits supplied to CalculateContext.
+type ErrBudgetExceeded struct {
+	Budget string
+	Limit  int
+}
+
+func (e ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("evaluation exceeded the %s budget of %d", e.Budget, e.Limit)
+}
+
+// ErrResultType represents an Expression resulting in a Value of a different kind to the one requested,
+// such as by CalculateBool.
+type ErrResultType struct {
+	// Expected is the kind of result requested.
+	Expected Kind
+	// Value is the result found.
+	Value Value
+}
+
+func (e ErrResultType) Error() string {
+	return fmt.Sprintf("expected a %s result but found `%s` (%s)", e.Expected, errorValue(e.Value.Any()), e.Value.Kind())
+}
+
+// errorValue formats a value for an error message, truncating long values.
+func errorValue(value any) string {
+	const limit = 64
+	s := formatValue(value)
+	if utf8.RuneCountInString(s) > limit {
+		s = string([]rune(s)[:limit]) + "..."
+	}
+	return s
 }
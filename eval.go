@@ -0,0 +1,212 @@
+package express
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// evaluator is implemented by all built-in expressions allowing them
+// to be evaluated against any Source and not only raw JSON.
+type evaluator interface {
+	eval(env *environment) (any, error)
+}
+
+// environment holds the state of a single evaluation of an expression tree.
+type environment struct {
+	src    Source
+	raw    []byte
+	memo   []memoResult
+	budget *budget
+	tracer *tracer
+}
+
+func newEnvironment(src Source) *environment {
+	return &environment{src: src}
+}
+
+// eval evaluates the expression, custom expressions which only
+// implement Calculate are supplied with the Source encoded as JSON.
+func (env *environment) eval(e Expression) (any, error) {
+	if env.tracer != nil {
+		return env.tracer.trace(e, func() (any, error) {
+			return env.enforce(e)
+		})
+	}
+	return env.enforce(e)
+}
+
+// enforce evaluates the expression within the budget, if any.
+func (env *environment) enforce(e Expression) (any, error) {
+	if env.budget == nil {
+		return env.evaluate(e)
+	}
+
+	if err := env.budget.visit(); err != nil {
+		return nil, err
+	}
+
+	value, err := env.evaluate(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// strings selected from the source are not produced by the expression
+	if _, ok := e.(selectorPath); !ok {
+		if err = env.budget.produce(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func (env *environment) evaluate(e Expression) (any, error) {
+	if ev, ok := e.(evaluator); ok {
+		return ev.eval(env)
+	}
+
+	src, err := env.json()
+	if err != nil {
+		return nil, err
+	}
+	return e.Calculate(src)
+}
+
+// iterate records an array element or character being iterated over.
+func (env *environment) iterate() error {
+	if env.budget == nil {
+		return nil
+	}
+	return env.budget.iterate()
+}
+
+func (env *environment) json() ([]byte, error) {
+	if env.raw == nil {
+		switch src := env.src.(type) {
+		case JSONSource:
+			env.raw = src
+		case *Document:
+			env.raw = src.Bytes()
+		default:
+			raw, err := json.Marshal(src)
+			if err != nil {
+				return nil, err
+			}
+			env.raw = raw
+		}
+	}
+	return env.raw, nil
+}
+
+// CalculateSource executes the parsed expression against the supplied Source,
+// allowing Go values to be used directly without first being encoded as JSON.
+func CalculateSource(e Expression, src Source) (any, error) {
+	return newEnvironment(src).eval(e)
+}
+
+// Limits are the budgets enforced by CalculateContext, a zero value meaning unlimited.
+type Limits struct {
+	// MaxNodes is the maximum number of expression nodes visited.
+	MaxNodes int
+	// MaxElements is the maximum number of array elements or characters iterated over by operators such as CONTAINS_ALL,
+	// every element and member within the arguments of a function call counting as iterated over.
+	MaxElements int
+	// MaxStringLength is the maximum length in bytes of any string produced by an expression,
+	// checked before calling functions with a Size such as REPEAT.
+	MaxStringLength int
+}
+
+// CalculateContext executes the parsed expression against the supplied JSON, returning
+// the context's error if it is cancelled and ErrBudgetExceeded if any of the Limits are exceeded.
+func CalculateContext(ctx context.Context, e Expression, src []byte, limits Limits) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	env := newEnvironment(JSONSource(src))
+	env.budget = &budget{ctx: ctx, limits: limits}
+	return env.eval(e)
+}
+
+// checkInterval is how many nodes or elements are visited between checks for cancellation.
+const checkInterval = 256
+
+// budget tracks the resources used by a single evaluation.
+type budget struct {
+	ctx      context.Context
+	limits   Limits
+	nodes    int
+	elements int
+}
+
+func (b *budget) visit() error {
+	b.nodes++
+	if b.limits.MaxNodes > 0 && b.nodes > b.limits.MaxNodes {
+		return ErrBudgetExceeded{Budget: "nodes", Limit: b.limits.MaxNodes}
+	}
+
+	if b.nodes%checkInterval == 0 {
+		return b.ctx.Err()
+	}
+	return nil
+}
+
+func (b *budget) produce(value any) error {
+	if s, ok := value.(string); ok && b.limits.MaxStringLength > 0 && len(s) > b.limits.MaxStringLength {
+		return ErrBudgetExceeded{Budget: "string length", Limit: b.limits.MaxStringLength}
+	}
+	return nil
+}
+
+func (b *budget) iterate() error {
+	b.elements++
+	if b.limits.MaxElements > 0 && b.elements > b.limits.MaxElements {
+		return ErrBudgetExceeded{Budget: "elements", Limit: b.limits.MaxElements}
+	}
+
+	if b.elements%checkInterval == 0 {
+		return b.ctx.Err()
+	}
+	return nil
+}
+
+// call accounts for a function called with the arguments, counting their elements as iterated over and
+// rejecting a result of the length in bytes supplied, zero when unknown, before the function produces it.
+func (b *budget) call(args []any, length int) error {
+	if b.limits.MaxStringLength > 0 && length > b.limits.MaxStringLength {
+		return ErrBudgetExceeded{Budget: "string length", Limit: b.limits.MaxStringLength}
+	}
+
+	for _, arg := range args {
+		if err := b.iterateWithin(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterateWithin counts every element of the arrays and objects within the value as iterated over.
+func (b *budget) iterateWithin(value any) error {
+	switch v := value.(type) {
+	case []any:
+		for _, e := range v {
+			if err := b.iterate(); err != nil {
+				return err
+			}
+
+			if err := b.iterateWithin(e); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for _, e := range v {
+			if err := b.iterate(); err != nil {
+				return err
+			}
+
+			if err := b.iterateWithin(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
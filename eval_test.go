@@ -0,0 +1,133 @@
+package express
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateContext(t *testing.T) {
+	arr := make([]string, 1000)
+	for i := range arr {
+		arr[i] = fmt.Sprint(i)
+	}
+	src := []byte(fmt.Sprintf(`{"name":"MyCompany","values":[%s]}`, strings.Join(arr, ",")))
+
+	tests := []struct {
+		name     string
+		exp      string
+		limits   Limits
+		expected any
+		err      error
+	}{
+		{
+			name:     "unlimited",
+			exp:      `.values CONTAINS_ALL .values`,
+			expected: true,
+		},
+		{
+			name:     "within budgets",
+			exp:      `.name + "!" == "MyCompany!" && 999 IN .values`,
+			limits:   Limits{MaxNodes: 9, MaxElements: 1000, MaxStringLength: 10},
+			expected: true,
+		},
+		{
+			name:   "nodes",
+			exp:    `.name + "!" == "MyCompany!" && 999 IN .values`,
+			limits: Limits{MaxNodes: 8},
+			err:    ErrBudgetExceeded{Budget: "nodes", Limit: 8},
+		},
+		{
+			name:   "elements",
+			exp:    `.values CONTAINS_ALL .values`,
+			limits: Limits{MaxElements: 10000},
+			err:    ErrBudgetExceeded{Budget: "elements", Limit: 10000},
+		},
+		{
+			name:     "short circuit",
+			exp:      `.values CONTAINS_ANY [0]`,
+			limits:   Limits{MaxElements: 1},
+			expected: true,
+		},
+		{
+			name:   "string length",
+			exp:    `.name + "!"`,
+			limits: Limits{MaxStringLength: 9},
+			err:    ErrBudgetExceeded{Budget: "string length", Limit: 9},
+		},
+		{
+			name:   "function arguments",
+			exp:    `LEN(SORT(.values)) == 1000`,
+			limits: Limits{MaxElements: 999},
+			err:    ErrBudgetExceeded{Budget: "elements", Limit: 999},
+		},
+		{
+			name:     "function arguments within budget",
+			exp:      `LEN(SORT(.values)) == 1000`,
+			limits:   Limits{MaxElements: 2000},
+			expected: true,
+		},
+		{
+			name:   "function result length",
+			exp:    `REPEAT(.name, 1000)`,
+			limits: Limits{MaxStringLength: 100},
+			err:    ErrBudgetExceeded{Budget: "string length", Limit: 100},
+		},
+		{
+			name:   "padded result length",
+			exp:    `PAD_LEFT(.name, 101)`,
+			limits: Limits{MaxStringLength: 100},
+			err:    ErrBudgetExceeded{Budget: "string length", Limit: 100},
+		},
+		{
+			name:     "selected string",
+			exp:      `.name`,
+			limits:   Limits{MaxStringLength: 2},
+			expected: "MyCompany",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			got, err := CalculateContext(context.Background(), ex, src, tc.limits)
+			if tc.err != nil {
+				var budgetErr ErrBudgetExceeded
+				assert.True(errors.As(err, &budgetErr))
+				assert.Equal(tc.err, budgetErr)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestCalculateContextCancelled(t *testing.T) {
+	assert := require.New(t)
+	ex, err := Parse([]byte(`.values CONTAINS_ALL .values`))
+	assert.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CalculateContext(ctx, ex, []byte(`{"values":[1,2,3]}`), Limits{})
+	assert.ErrorIs(err, context.Canceled)
+
+	arr := make([]string, 1000)
+	for i := range arr {
+		arr[i] = fmt.Sprint(i)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	env := newEnvironment(JSONSource(fmt.Sprintf(`{"values":[%s]}`, strings.Join(arr, ","))))
+	env.budget = &budget{ctx: ctx, limits: Limits{}}
+	cancel()
+	_, err = env.eval(ex)
+	assert.ErrorIs(err, context.Canceled)
+}
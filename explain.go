@@ -0,0 +1,85 @@
+package express
+
+import "strings"
+
+// Trace is the evaluation of a node of an expression tree, mirroring the tree
+// so it can be seen which parts of an expression caused its result.
+type Trace struct {
+	// Expression is the node in the express syntax.
+	Expression string
+	// Value is the result of evaluating the node.
+	Value any
+	// Err is the error evaluating the node, if any.
+	Err error
+	// ShortCircuited is true when the node was not evaluated as
+	// the result of its parent `&&` or `||` was already known.
+	ShortCircuited bool
+	// Children are the traces of the node's operands in order.
+	Children []*Trace
+}
+
+// Explain executes the parsed expression against the supplied JSON, the same as Calculate,
+// returning the Trace of the evaluation along with any error.
+func Explain(e Expression, src []byte) (*Trace, error) {
+	env := newEnvironment(JSONSource(src))
+	env.tracer = new(tracer)
+	_, err := env.eval(e)
+	return env.tracer.root, err
+}
+
+// String renders the trace as indented text, one node per line.
+func (t *Trace) String() string {
+	var sb strings.Builder
+	t.write(&sb, 0)
+	return sb.String()
+}
+
+func (t *Trace) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString(t.Expression)
+	sb.WriteString(" => ")
+	switch {
+	case t.ShortCircuited:
+		sb.WriteString("short-circuited")
+	case t.Err != nil:
+		sb.WriteString("error: ")
+		sb.WriteString(t.Err.Error())
+	default:
+		sb.WriteString(formatValue(t.Value))
+	}
+	sb.WriteByte('\n')
+
+	for _, child := range t.Children {
+		child.write(sb, depth+1)
+	}
+}
+
+// tracer records the Trace of an evaluation.
+type tracer struct {
+	root  *Trace
+	stack []*Trace
+}
+
+// trace records the evaluation of the expression as a child of the node currently being evaluated.
+func (t *tracer) trace(e Expression, eval func() (any, error)) (any, error) {
+	node := &Trace{Expression: format(e)}
+	if n := len(t.stack); n > 0 {
+		t.stack[n-1].Children = append(t.stack[n-1].Children, node)
+	} else {
+		t.root = node
+	}
+
+	t.stack = append(t.stack, node)
+	node.Value, node.Err = eval()
+	t.stack = t.stack[:len(t.stack)-1]
+
+	switch e.(type) {
+	case and, or:
+		if node.Err == nil {
+			for _, child := range children(e)[len(node.Children):] {
+				node.Children = append(node.Children, &Trace{Expression: format(child), ShortCircuited: true})
+			}
+		}
+	}
+	return node.Value, node.Err
+}
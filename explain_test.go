@@ -0,0 +1,80 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		exp      string
+		src      string
+		expected string
+		err      bool
+	}{
+		{
+			name: "and short circuit",
+			exp:  `.a == 1 && .b IN ["x","y"]`,
+			src:  `{"a":2,"b":"x"}`,
+			expected: `(.a == 1) && (.b IN ["x", "y"]) => false
+  .a == 1 => false
+    .a => 2
+    1 => 1
+  .b IN ["x", "y"] => short-circuited
+`,
+		},
+		{
+			name: "or",
+			exp:  `.a != 1 || !.b`,
+			src:  `{"a":1,"b":false}`,
+			expected: `(.a != 1) || !.b => true
+  .a != 1 => false
+    .a == 1 => true
+      .a => 1
+      1 => 1
+  !.b => true
+    .b => false
+`,
+		},
+		{
+			name: "coerce and between",
+			exp:  `COERCE .name _substr_[0:2] == "My" && .count BETWEEN 1 10`,
+			src:  `{"name":"MyCompany","count":5}`,
+			expected: `(COERCE .name _substr_[0:2] == "My") && (.count BETWEEN 1 10) => true
+  COERCE .name _substr_[0:2] == "My" => true
+    COERCE .name _substr_[0:2] => "My"
+      .name => "MyCompany"
+    "My" => "My"
+  .count BETWEEN 1 10 => true
+    1 => 1
+    10 => 10
+    .count => 5
+`,
+		},
+		{
+			name:     "error",
+			exp:      `.a > 1`,
+			src:      `{"a":"b"}`,
+			expected: ".a > 1 => error: unsupported type comparison: `\"b\" > 1` (string > number) at [0:6]\n  .a => \"b\"\n  1 => 1\n",
+			err:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			trace, err := Explain(ex, []byte(tc.src))
+			if tc.err {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tc.expected, trace.String())
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package express
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// format returns the textual form of an expression in the express syntax.
+// Custom expressions are formatted by their Go type.
+func format(e Expression) string {
+	switch t := e.(type) {
+	case between:
+		return fmt.Sprintf("%s BETWEEN %s %s", format(t.value), format(t.left), format(t.right))
+	case add:
+		return formatBinary(t.left, "+", t.right)
+	case sub:
+		return formatBinary(t.left, "-", t.right)
+	case multi:
+		return formatBinary(t.left, "*", t.right)
+	case div:
+		return formatBinary(t.left, "/", t.right)
+	case eq:
+		if left, right, ok := unfold(t.left, t.right); ok {
+			return formatBinary(left, "~=", right)
+		}
+		return formatBinary(t.left, "==", t.right)
+	case gt:
+		return formatBinary(t.left, ">", t.right)
+	case gte:
+		return formatBinary(t.left, ">=", t.right)
+	case lt:
+		return formatBinary(t.left, "<", t.right)
+	case lte:
+		return formatBinary(t.left, "<=", t.right)
+	case or:
+		return formatBinary(t.left, "||", t.right)
+	case and:
+		return formatBinary(t.left, "&&", t.right)
+	case startsWith:
+		if left, right, ok := unfold(t.left, t.right); ok {
+			return formatBinary(left, "ISTARTSWITH", right)
+		}
+		return formatBinary(t.left, "STARTSWITH", t.right)
+	case endsWith:
+		if left, right, ok := unfold(t.left, t.right); ok {
+			return formatBinary(left, "IENDSWITH", right)
+		}
+		return formatBinary(t.left, "ENDSWITH", t.right)
+	case in:
+		if left, right, ok := unfold(t.left, t.right); ok {
+			return formatBinary(left, "IIN", right)
+		}
+		return formatBinary(t.left, "IN", t.right)
+	case contains:
+		if left, right, ok := unfold(t.left, t.right); ok {
+			return formatBinary(left, "ICONTAINS", right)
+		}
+		return formatBinary(t.left, "CONTAINS", t.right)
+	case containsAny:
+		return formatBinary(t.left, "CONTAINS_ANY", t.right)
+	case containsAll:
+		return formatBinary(t.left, "CONTAINS_ALL", t.right)
+	case not:
+		switch inner := t.value.(type) {
+		case eq:
+			if left, right, ok := unfold(inner.left, inner.right); ok {
+				return formatBinary(left, "!~=", right)
+			}
+			return formatBinary(inner.left, "!=", inner.right)
+		case isNull:
+			return formatOperand(inner.value) + " IS NOT NULL"
+		case exists:
+			return "." + inner.s + " IS MISSING"
+		case isType:
+			return formatOperand(inner.value) + " IS NOT " + strings.ToUpper(inner.kind.String())
+		}
+		return "!" + formatOperand(t.value)
+	case exists:
+		return "EXISTS ." + t.s
+	case isNull:
+		return formatOperand(t.value) + " IS NULL"
+	case fold:
+		// only found within case-insensitive operators
+		return "FOLD(" + format(t.value) + ")"
+	case isType:
+		return formatOperand(t.value) + " IS " + strings.ToUpper(t.kind.String())
+	case call:
+		args := make([]string, 0, len(t.args))
+		for _, arg := range t.args {
+			args = append(args, format(arg))
+		}
+		return t.name + "(" + strings.Join(args, ", ") + ")"
+	case setOperation:
+		return formatBinary(t.left, setOperators[t.op], t.right)
+	case hasKey:
+		return formatBinary(t.left, t.op(), t.right)
+	case index:
+		return formatPostfix(t.value) + "[" + strconv.Itoa(t.i) + "]"
+	case member:
+		return formatPostfix(t.value) + "." + t.path
+	case slice:
+		var start, end string
+		if t.start.IsSome() {
+			start = strconv.Itoa(t.start.Unwrap())
+		}
+		if t.end.IsSome() {
+			end = strconv.Itoa(t.end.Unwrap())
+		}
+		return formatPostfix(t.value) + fmt.Sprintf("[%s:%s]", start, end)
+	case array:
+		elems := make([]string, 0, len(t.vec))
+		for _, v := range t.vec {
+			elems = append(elems, format(v))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case num:
+		return strconv.FormatFloat(t.n, 'f', -1, 64)
+	case str:
+		return strconv.Quote(t.s)
+	case boolean:
+		return strconv.FormatBool(t.b)
+	case null:
+		return "NULL"
+	case selectorPath:
+		return "." + t.s
+	case coercedConstant:
+		return formatValue(t.value)
+	case coerceString:
+		return formatCoerce(t.value, "_string_")
+	case coerceDateTime:
+		return formatCoerce(t.value, "_datetime_")
+	case coerceUppercase:
+		return formatCoerce(t.value, "_uppercase_")
+	case coerceLowercase:
+		return formatCoerce(t.value, "_lowercase_")
+	case coerceNumber:
+		return formatCoerce(t.value, "_number_")
+	case coerceTitle:
+		return formatCoerce(t.value, "_title_")
+	case coerceSubstr:
+		var start, end string
+		if t.start.IsSome() {
+			start = strconv.Itoa(t.start.Unwrap())
+		}
+		if t.end.IsSome() {
+			end = strconv.Itoa(t.end.Unwrap())
+		}
+		return formatCoerce(t.value, fmt.Sprintf("_substr_[%s:%s]", start, end))
+	case *memo:
+		return format(t.value)
+	case invalid:
+		return "<invalid>"
+	default:
+		return fmt.Sprintf("%T", e)
+	}
+}
+
+func formatBinary(left Expression, op string, right Expression) string {
+	return formatOperand(left) + " " + op + " " + formatOperand(right)
+}
+
+func formatCoerce(value Expression, name string) string {
+	return "COERCE " + formatOperand(value) + " " + name
+}
+
+// formatOperand formats an operand, wrapping it in parentheses unless it is a leaf or unary operator.
+func formatOperand(e Expression) string {
+	switch t := e.(type) {
+	case num, str, boolean, null, selectorPath, coercedConstant, array, invalid, exists, call, fold, slice, index, member,
+		coerceString, coerceDateTime, coerceUppercase, coerceLowercase, coerceNumber, coerceTitle, coerceSubstr:
+		return format(e)
+	case not:
+		switch t.value.(type) {
+		case eq, isNull, isType, exists:
+		default:
+			return format(e)
+		}
+	}
+	return "(" + format(e) + ")"
+}
+
+// formatPostfix formats the value of a postfix operator, wrapping it in parentheses unless it is a leaf.
+func formatPostfix(e Expression) string {
+	switch e.(type) {
+	case num, str, boolean, null, selectorPath, coercedConstant, array, invalid, call, slice, index, member:
+		return format(e)
+	}
+	return "(" + format(e) + ")"
+}
+
+// formatValue returns the JSON representation of an evaluated value.
+func formatValue(value any) string {
+	if t, ok := value.(time.Time); ok {
+		return strconv.Quote(t.Format(time.RFC3339Nano))
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
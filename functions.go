@@ -0,0 +1,198 @@
+package express
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pchchv/extender/syncext"
+)
+
+// MaxResultLength is the length in bytes of the longest string a Function with a Size may produce,
+// guarding against expressions such as `REPEAT("abc", 2147483647)` exhausting memory.
+const MaxResultLength = 1 << 20
+
+var (
+	_ Expression = (*call)(nil)
+	// Functions is a `map` of all functions callable by name, such as `TYPEOF(.a)`, guarded by a Mutex
+	// allowing registration, removal or even replacing of existing functions.
+	//
+	// Function names must be upper case letters, digits and underscores.
+	Functions = syncext.NewRWMutex(map[string]Function{
+		"TYPEOF": {
+			MinArgs:       1,
+			MaxArgs:       1,
+			Deterministic: true,
+			Call: func(args []any) (any, error) {
+				return typeOf(args[0]), nil
+			},
+		},
+		"LEN":        {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: length},
+		"TRIM":       {MinArgs: 1, MaxArgs: 2, Deterministic: true, Call: trim(strings.Trim, strings.TrimSpace)},
+		"TRIM_LEFT":  {MinArgs: 1, MaxArgs: 2, Deterministic: true, Call: trim(strings.TrimLeft, trimLeftSpace)},
+		"TRIM_RIGHT": {MinArgs: 1, MaxArgs: 2, Deterministic: true, Call: trim(strings.TrimRight, trimRightSpace)},
+		"SPLIT":      {MinArgs: 2, MaxArgs: 2, Deterministic: true, Call: split},
+		"JOIN":       {MinArgs: 2, MaxArgs: 2, Deterministic: true, Call: join},
+		"REPLACE":    {MinArgs: 3, MaxArgs: 3, Deterministic: true, Call: replace},
+		"INDEX_OF":   {MinArgs: 2, MaxArgs: 2, Deterministic: true, Call: indexOf(0), tolerant: indexOf},
+		"PAD_LEFT":   {MinArgs: 2, MaxArgs: 3, Deterministic: true, Call: pad(true), Size: padSize},
+		"PAD_RIGHT":  {MinArgs: 2, MaxArgs: 3, Deterministic: true, Call: pad(false), Size: padSize},
+		"REPEAT":     {MinArgs: 2, MaxArgs: 2, Deterministic: true, Call: repeat, Size: repeatSize},
+		"SUM":        {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: sum},
+		"AVG":        {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: avg},
+		"MIN":        {MinArgs: 1, MaxArgs: -1, Deterministic: true, Call: extreme(false)},
+		"MAX":        {MinArgs: 1, MaxArgs: -1, Deterministic: true, Call: extreme(true)},
+		"COUNT":      {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: count},
+		"ABS":        {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: unary(math.Abs)},
+		"FLOOR":      {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: unary(math.Floor)},
+		"CEIL":       {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: unary(math.Ceil)},
+		"SQRT":       {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: unary(math.Sqrt)},
+		"ROUND":      {MinArgs: 1, MaxArgs: 3, Deterministic: true, Call: round},
+		"POW":        {MinArgs: 2, MaxArgs: 2, Deterministic: true, Call: pow},
+		"LOG":        {MinArgs: 1, MaxArgs: 2, Deterministic: true, Call: logarithm},
+		"KEYS":       {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: keys},
+		"VALUES":     {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: values},
+		"SORT":       {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: sortArray},
+		"DISTINCT":   {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: distinct(0), tolerant: distinct},
+		"FLATTEN":    {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: flatten},
+		"FIRST":      {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: element(false)},
+		"LAST":       {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: element(true)},
+		"REVERSE":    {MinArgs: 1, MaxArgs: 1, Deterministic: true, Call: reverse},
+	})
+)
+
+// Function is a function callable by name within an expression.
+type Function struct {
+	// MinArgs and MaxArgs are the number of arguments accepted, a negative MaxArgs being unlimited.
+	MinArgs, MaxArgs int
+	// Deterministic functions always return the same result for the same arguments,
+	// allowing calls with constant arguments to be calculated once when parsed.
+	Deterministic bool
+	// Call returns the result of the function applied to its evaluated arguments.
+	//
+	// An ErrInvalidArgument returned for an argument of an unsupported type or value
+	// follows the Mode the expression was parsed with.
+	Call func(args []any) (any, error)
+	// Size, if set, returns the length in bytes of the string Call would produce for the arguments,
+	// allowing the call to be rejected before it allocates a result longer than MaxResultLength.
+	Size func(args []any) int
+	// tolerant returns Call comparing numbers within the Tolerance the expression was parsed with,
+	// for the built-in functions comparing elements the same as CONTAINS.
+	tolerant func(tolerance float64) func(args []any) (any, error)
+}
+
+// arity describes the number of arguments accepted for error messages.
+func (f Function) arity() string {
+	switch {
+	case f.MinArgs == f.MaxArgs:
+		return plural(f.MinArgs, "argument")
+	case f.MaxArgs < 0:
+		return "at least " + plural(f.MinArgs, "argument")
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.MinArgs, f.MaxArgs)
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// call is a call to a Function.
+type call struct {
+	name string
+	fn   Function
+	args []Expression
+	span Span
+	opts ParseOptions
+}
+
+func (c call) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c call) eval(env *environment) (any, error) {
+	args := make([]any, 0, len(c.args))
+	for _, arg := range c.args {
+		value, err := env.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	var length int
+	if c.fn.Size != nil {
+		if length = c.fn.Size(args); length > MaxResultLength {
+			return nil, ErrResultTooLong{Function: c.name, Length: length, Span: c.span}
+		}
+	}
+
+	if env.budget != nil {
+		if err := env.budget.call(args, length); err != nil {
+			return nil, err
+		}
+	}
+
+	fn := c.fn.Call
+	if c.fn.tolerant != nil && c.opts.Tolerance != 0 {
+		fn = c.fn.tolerant(c.opts.Tolerance)
+	}
+
+	value, err := fn(args)
+	if err != nil {
+		var argErr ErrInvalidArgument
+		if errors.As(err, &argErr) {
+			argErr.Function, argErr.Span = c.name, c.span
+			return c.opts.mismatch(nil, argErr)
+		}
+
+		var finiteErr ErrNotFinite
+		if errors.As(err, &finiteErr) {
+			finiteErr.Function, finiteErr.Span = c.name, c.span
+			return c.opts.mismatch(nil, finiteErr)
+		}
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	return value, nil
+}
+
+// nullArg returns if any of the arguments are null, for functions resulting in null for a null argument.
+func nullArg(args []any) bool {
+	for _, arg := range args {
+		if arg == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// stringArg returns the argument at the index as a string.
+func stringArg(args []any, i int) (string, error) {
+	s, ok := args[i].(string)
+	if !ok {
+		return "", ErrInvalidArgument{Index: i, Value: args[i], Expected: "string"}
+	}
+	return s, nil
+}
+
+// numberArg returns the argument at the index as a number.
+func numberArg(args []any, i int) (float64, error) {
+	f, ok := args[i].(float64)
+	if !ok {
+		return 0, ErrInvalidArgument{Index: i, Value: args[i], Expected: "number"}
+	}
+	return f, nil
+}
+
+// countArg returns the argument at the index as a non-negative integer.
+func countArg(args []any, i int) (int, error) {
+	f, ok := args[i].(float64)
+	if !ok || f < 0 || f != math.Trunc(f) || f > math.MaxInt32 {
+		return 0, ErrInvalidArgument{Index: i, Value: args[i], Expected: "non-negative integer"}
+	}
+	return int(f), nil
+}
@@ -0,0 +1,177 @@
+package express
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctions(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "typeof string",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":"x"}`,
+			expected: "string",
+		},
+		{
+			name:     "typeof number",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":1}`,
+			expected: "number",
+		},
+		{
+			name:     "typeof bool",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":true}`,
+			expected: "bool",
+		},
+		{
+			name:     "typeof array",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":[1]}`,
+			expected: "array",
+		},
+		{
+			name:     "typeof object",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":{}}`,
+			expected: "object",
+		},
+		{
+			name:     "typeof null",
+			exp:      `TYPEOF(.a)`,
+			src:      `{"a":null}`,
+			expected: "null",
+		},
+		{
+			name:     "typeof missing",
+			exp:      `TYPEOF(.a)`,
+			src:      `{}`,
+			expected: "null",
+		},
+		{
+			name:     "typeof datetime",
+			exp:      `TYPEOF(COERCE .a _datetime_)`,
+			src:      `{"a":"2024-01-02"}`,
+			expected: "datetime",
+		},
+		{
+			name:     "typeof expression",
+			exp:      `TYPEOF(.a + 1) == "number"`,
+			src:      `{"a":1}`,
+			expected: true,
+		},
+		{
+			name:     "typeof parenthesized",
+			exp:      `TYPEOF((.a == 1 || .b)) == "bool"`,
+			src:      `{"a":1}`,
+			expected: true,
+		},
+		{
+			name:     "typeof branching",
+			exp:      `(TYPEOF(.a) == "string" && .a == "1") || (TYPEOF(.a) == "number" && .a == 1)`,
+			src:      `{"a":1}`,
+			expected: true,
+		},
+		{
+			name:     "unknown function",
+			exp:      `NOPE(.a)`,
+			parseErr: errors.New("unknown function 'NOPE'"),
+		},
+		{
+			name:     "too few arguments",
+			exp:      `TYPEOF()`,
+			parseErr: errors.New("TYPEOF expects 1 argument, found 0"),
+		},
+		{
+			name:     "too many arguments",
+			exp:      `TYPEOF(.a, .b)`,
+			parseErr: errors.New("TYPEOF expects 1 argument, found 2"),
+		},
+		{
+			name:     "missing argument",
+			exp:      `TYPEOF(.a,)`,
+			parseErr: errors.New("missing argument to TYPEOF"),
+		},
+		{
+			name:     "unclosed",
+			exp:      `TYPEOF(.a`,
+			parseErr: errors.New("unclosed function call TYPEOF("),
+		},
+	})
+}
+
+func TestCustomFunction(t *testing.T) {
+	assert := require.New(t)
+	guard := Functions.Lock()
+	guard.T["JOIN_ALL"] = Function{
+		MinArgs: 1,
+		MaxArgs: -1,
+		Call: func(args []any) (any, error) {
+			parts := make([]string, 0, len(args))
+			for _, arg := range args {
+				s, ok := arg.(string)
+				if !ok {
+					return nil, errors.New("arguments must be strings")
+				}
+				parts = append(parts, s)
+			}
+			return strings.Join(parts, ""), nil
+		},
+	}
+	guard.Unlock()
+
+	exp := []byte(`JOIN_ALL(.a, "-", .b)`)
+	ex, err := Parse(exp)
+	assert.NoError(err)
+	assert.Equal(`JOIN_ALL(.a, "-", .b)`, format(ex))
+
+	result, err := ex.Calculate([]byte(`{"a":"x","b":"y"}`))
+	assert.NoError(err)
+	assert.Equal("x-y", result)
+
+	_, err = ex.Calculate([]byte(`{"a":1,"b":"y"}`))
+	assert.EqualError(err, "JOIN_ALL: arguments must be strings")
+}
+
+// calculateTest is a table test case calculating an expression parsed with the options against the JSON source.
+type calculateTest struct {
+	name     string
+	exp      string
+	opts     ParseOptions
+	src      string
+	expected any
+	err      error
+	parseErr error
+}
+
+func runCalculateTests(t *testing.T, tests []calculateTest) {
+	t.Helper()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := ParseWithOptions([]byte(tc.exp), tc.opts)
+			if tc.parseErr != nil {
+				var parseErr ParseError
+				assert.True(errors.As(err, &parseErr))
+				assert.IsType(tc.parseErr, parseErr.Err)
+				assert.EqualError(parseErr.Err, tc.parseErr.Error())
+				return
+			}
+			assert.NoError(err)
+
+			got, err := ex.Calculate([]byte(tc.src))
+			if tc.err != nil {
+				// compared by type and message, NaN values never being equal
+				assert.IsType(tc.err, err)
+				assert.EqualError(err, tc.err.Error())
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
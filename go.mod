@@ -8,6 +8,7 @@ require (
 	github.com/pchchv/goitertools v1.0.0
 	github.com/stretchr/testify v1.10.0
 	github.com/tidwall/gjson v1.18.0
+	golang.org/x/text v0.28.0
 )
 
 require (
@@ -39,6 +39,21 @@ const (
 	Coerce
 	Identifier
 	Colon
+	Exists
+	Is
+	FunctionName
+	EqualsFold
+	StartsWithFold
+	EndsWithFold
+	ContainsFold
+	InFold
+	Intersects
+	SubsetOf
+	SupersetOf
+	Disjoint
+	SetEquals
+	HasKey
+	HasAnyKeys
 )
 
 // TokenKind is the type of token lexed.
@@ -100,6 +115,19 @@ func (t *Tokenizer) chomp(num uint16) {
 	t.pos += uint32(num)
 }
 
+// skipInvalid skips input that could not be lexed up to the next whitespace or delimiter.
+func (t *Tokenizer) skipInvalid() {
+	skipped := takeWhile(t.remaining, func(b byte) bool {
+		switch b {
+		case '(', ')', '[', ']', ',', '&', '|':
+			return false
+		default:
+			return !isWhitespace(b)
+		}
+	})
+	t.chomp(max(skipped, 1))
+}
+
 func (t *Tokenizer) skipWhitespace() {
 	skipped := skipWhitespace(t.remaining)
 	t.chomp(skipped)
@@ -140,6 +168,18 @@ func skipWhitespace(data []byte) uint16 {
 	})
 }
 
+// word returns the data up to the first whitespace or bracket, keeping errors to the offending input.
+func word(data []byte) string {
+	return string(data[:takeWhile(data, func(b byte) bool {
+		switch b {
+		case '(', ')', '[', ']', ',':
+			return false
+		default:
+			return !isWhitespace(b)
+		}
+	})])
+}
+
 // takeWhile сonsumes bytes while a predicate evaluates to true.
 func takeWhile(data []byte, pred func(byte) bool) (end uint16) {
 	for _, b := range data {
@@ -152,8 +192,26 @@ func takeWhile(data []byte, pred func(byte) bool) (end uint16) {
 }
 
 func tokenizeSelectorPath(data []byte) (result LexerResult, err error) {
+	var lastBackslash bool
+	var braces int
 	if end := takeWhile(data[1:], func(b byte) bool {
-		return !isWhitespace(b) && b != ')' && b != ']'
+		if lastBackslash {
+			// an escaped character, such as `\[` or `\,`, is part of the path
+			lastBackslash = false
+			return true
+		}
+		lastBackslash = b == '\\'
+
+		switch b {
+		case '{':
+			braces++
+		case '}':
+			braces--
+		case ',':
+			// separates the paths of a gjson multipath such as `.{a,b}`, otherwise arguments or elements
+			return braces > 0
+		}
+		return !isWhitespace(b) && b != ')' && b != '[' && b != ']'
 	}); end > 0 {
 		if len(data) > int(end) {
 			end += 1
@@ -163,7 +221,7 @@ func tokenizeSelectorPath(data []byte) (result LexerResult, err error) {
 			len:  end,
 		}
 	} else {
-		err = ErrInvalidSelectorPath{s: string(data)}
+		err = ErrInvalidSelectorPath{s: word(data)}
 	}
 
 	return
@@ -218,7 +276,7 @@ func tokenizeNull(data []byte) (result LexerResult, err error) {
 			len:  end,
 		}
 	} else {
-		err = ErrInvalidKeyword{s: string(data)}
+		err = ErrInvalidKeyword{s: word(data)}
 	}
 	return
 }
@@ -239,10 +297,10 @@ func tokenizeBool(data []byte) (result LexerResult, err error) {
 				len:  end,
 			}
 		default:
-			err = ErrInvalidBool{s: string(data)}
+			err = ErrInvalidBool{s: word(data)}
 		}
 	} else {
-		err = ErrInvalidBool{s: string(data)}
+		err = ErrInvalidBool{s: word(data)}
 	}
 	return
 }
@@ -269,7 +327,7 @@ func tokenizeNumber(data []byte) (result LexerResult, err error) {
 			len:  end,
 		}
 	} else {
-		err = ErrInvalidNumber{s: string(data)}
+		err = ErrInvalidNumber{s: word(data)}
 	}
 	return
 }
@@ -283,7 +341,7 @@ func tokenizeIdentifier(data []byte) (result LexerResult, err error) {
 			len:  end,
 		}
 	} else {
-		err = ErrInvalidIdentifier{s: string(data)}
+		err = ErrInvalidIdentifier{s: word(data)}
 	}
 	return
 }
@@ -297,7 +355,52 @@ func tokenizeKeyword(data []byte, keyword string, kind TokenKind) (result LexerR
 			len:  end,
 		}
 	} else {
-		err = ErrInvalidKeyword{s: string(data)}
+		err = ErrInvalidKeyword{s: word(data)}
+	}
+	return
+}
+
+// tokenizeIs lexes a test such as `IS NULL` or `IS NOT MISSING` as a single token,
+// the keyword tested for being validated when parsed.
+func tokenizeIs(data []byte) (result LexerResult, err error) {
+	var end uint16
+	keyword := func() string {
+		start := end
+		end += takeWhile(data[end:], isUpper)
+		return string(data[start:end])
+	}
+	separated := func() bool {
+		skipped := skipWhitespace(data[end:])
+		end += skipped
+		return skipped > 0
+	}
+
+	if keyword() != "IS" || !separated() {
+		return result, ErrInvalidKeyword{s: word(data)}
+	}
+
+	k := keyword()
+	if k == "NOT" {
+		if !separated() {
+			return result, ErrInvalidKeyword{s: string(data[:end])}
+		}
+		k = keyword()
+	}
+
+	if k == "" {
+		return result, ErrInvalidKeyword{s: string(data[:end]) + word(data[end:])}
+	}
+	return LexerResult{kind: Is, len: end}, nil
+}
+
+// tokenizeFunctionName lexes the name of a function call, being upper case letters,
+// digits and underscores immediately followed by the opening parenthesis of its arguments.
+func tokenizeFunctionName(data []byte) (result LexerResult, ok bool) {
+	end := takeWhile(data, func(b byte) bool {
+		return isUpper(b) || isDigit(b) || b == '_'
+	})
+	if int(end) < len(data) && data[end] == '(' {
+		return LexerResult{kind: FunctionName, len: end}, true
 	}
 	return
 }
@@ -305,6 +408,12 @@ func tokenizeKeyword(data []byte, keyword string, kind TokenKind) (result LexerR
 // Try to lex a single token from the input stream.
 func tokenizeSingleToken(data []byte) (result LexerResult, err error) {
 	b := data[0]
+	if isUpper(b) {
+		if result, ok := tokenizeFunctionName(data); ok {
+			return result, nil
+		}
+	}
+
 	switch b {
 	case '=':
 		if len(data) > 1 && data[1] == '=' {
@@ -312,6 +421,12 @@ func tokenizeSingleToken(data []byte) (result LexerResult, err error) {
 		} else {
 			result = LexerResult{kind: Equals, len: 1}
 		}
+	case '~':
+		if len(data) > 1 && data[1] == '=' {
+			result = LexerResult{kind: EqualsFold, len: 2}
+		} else {
+			err = ErrUnsupportedCharacter{b: b}
+		}
 	case '+':
 		if len(data) > 1 && isDigit(data[1]) {
 			result, err = tokenizeNumber(data)
@@ -390,13 +505,49 @@ func tokenizeSingleToken(data []byte) (result LexerResult, err error) {
 			result, err = tokenizeKeyword(data, "COERCE", Coerce)
 		}
 	case 'I':
-		result, err = tokenizeKeyword(data, "IN", In)
+		switch {
+		case len(data) > 2 && data[1] == 'S' && data[2] == 'T':
+			result, err = tokenizeKeyword(data, "ISTARTSWITH", StartsWithFold)
+		case len(data) > 1 && data[1] == 'S':
+			result, err = tokenizeIs(data)
+		case len(data) > 1 && data[1] == 'E':
+			result, err = tokenizeKeyword(data, "IENDSWITH", EndsWithFold)
+		case len(data) > 1 && data[1] == 'C':
+			result, err = tokenizeKeyword(data, "ICONTAINS", ContainsFold)
+		case len(data) > 1 && data[1] == 'I':
+			result, err = tokenizeKeyword(data, "IIN", InFold)
+		case len(data) > 2 && data[1] == 'N' && data[2] == 'T':
+			result, err = tokenizeKeyword(data, "INTERSECTS", Intersects)
+		default:
+			result, err = tokenizeKeyword(data, "IN", In)
+		}
 	case 'S':
-		result, err = tokenizeKeyword(data, "STARTSWITH", StartsWith)
+		switch {
+		case len(data) > 2 && data[1] == 'U' && data[2] == 'B':
+			result, err = tokenizeKeyword(data, "SUBSET_OF", SubsetOf)
+		case len(data) > 1 && data[1] == 'U':
+			result, err = tokenizeKeyword(data, "SUPERSET_OF", SupersetOf)
+		case len(data) > 1 && data[1] == 'E':
+			result, err = tokenizeKeyword(data, "SET_EQUALS", SetEquals)
+		default:
+			result, err = tokenizeKeyword(data, "STARTSWITH", StartsWith)
+		}
+	case 'D':
+		result, err = tokenizeKeyword(data, "DISJOINT", Disjoint)
 	case 'E':
-		result, err = tokenizeKeyword(data, "ENDSWITH", EndsWith)
+		if len(data) > 1 && data[1] == 'X' {
+			result, err = tokenizeKeyword(data, "EXISTS", Exists)
+		} else {
+			result, err = tokenizeKeyword(data, "ENDSWITH", EndsWith)
+		}
 	case 'B':
 		result, err = tokenizeKeyword(data, "BETWEEN", Between)
+	case 'H':
+		if len(data) > 4 && data[4] == 'A' {
+			result, err = tokenizeKeyword(data, "HAS_ANY_KEYS", HasAnyKeys)
+		} else {
+			result, err = tokenizeKeyword(data, "HAS_KEY", HasKey)
+		}
 	case 'N':
 		result, err = tokenizeNull(data)
 	case '_':
@@ -359,6 +359,76 @@ func Test(t *testing.T) {
 			input:  " +1e10 ",
 			tokens: []Token{{Kind: Number, Start: 1, Len: 5}},
 		},
+		{
+			name:   "parse exists",
+			input:  "EXISTS .a",
+			tokens: []Token{{Kind: Exists, Start: 0, Len: 6}, {Kind: SelectorPath, Start: 7, Len: 2}},
+		},
+		{
+			name:   "parse is null",
+			input:  ".a IS NULL",
+			tokens: []Token{{Kind: SelectorPath, Start: 0, Len: 2}, {Kind: Is, Start: 3, Len: 7}},
+		},
+		{
+			name:   "parse is not missing",
+			input:  ".a IS  NOT MISSING)",
+			tokens: []Token{{Kind: SelectorPath, Start: 0, Len: 2}, {Kind: Is, Start: 3, Len: 15}, {Kind: CloseParen, Start: 18, Len: 1}},
+		},
+		{
+			name:  "parse is without test",
+			input: ".a IS NOT",
+			err:   ErrInvalidKeyword{s: "IS NOT"},
+		},
+		{
+			name:   "parse function name",
+			input:  "TYPEOF(.a)",
+			tokens: []Token{{Kind: FunctionName, Start: 0, Len: 6}, {Kind: OpenParen, Start: 6, Len: 1}, {Kind: SelectorPath, Start: 7, Len: 2}, {Kind: CloseParen, Start: 9, Len: 1}},
+		},
+		{
+			name:   "parse selector path multipath",
+			input:  ".{a,b},.c\\,d",
+			tokens: []Token{{Kind: SelectorPath, Start: 0, Len: 6}, {Kind: Comma, Start: 6, Len: 1}, {Kind: SelectorPath, Start: 7, Len: 5}},
+		},
+		{
+			name:   "parse selector path before comma",
+			input:  "[.a,.b]",
+			tokens: []Token{{Kind: OpenBracket, Start: 0, Len: 1}, {Kind: SelectorPath, Start: 1, Len: 2}, {Kind: Comma, Start: 3, Len: 1}, {Kind: SelectorPath, Start: 4, Len: 2}, {Kind: CloseBracket, Start: 6, Len: 1}},
+		},
+		{
+			name:   "parse selector path before slice",
+			input:  ".a[-1:]",
+			tokens: []Token{{Kind: SelectorPath, Start: 0, Len: 2}, {Kind: OpenBracket, Start: 2, Len: 1}, {Kind: Number, Start: 3, Len: 2}, {Kind: Colon, Start: 5, Len: 1}, {Kind: CloseBracket, Start: 6, Len: 1}},
+		},
+		{
+			name:   "parse set operators",
+			input:  "INTERSECTS SUBSET_OF SUPERSET_OF DISJOINT SET_EQUALS STARTSWITH IN ",
+			tokens: []Token{{Kind: Intersects, Start: 0, Len: 10}, {Kind: SubsetOf, Start: 11, Len: 9}, {Kind: SupersetOf, Start: 21, Len: 11}, {Kind: Disjoint, Start: 33, Len: 8}, {Kind: SetEquals, Start: 42, Len: 10}, {Kind: StartsWith, Start: 53, Len: 10}, {Kind: In, Start: 64, Len: 2}},
+		},
+		{
+			name:   "parse has key operators",
+			input:  "HAS_KEY HAS_ANY_KEYS ",
+			tokens: []Token{{Kind: HasKey, Start: 0, Len: 7}, {Kind: HasAnyKeys, Start: 8, Len: 12}},
+		},
+		{
+			name:   "parse function name keyword",
+			input:  "IN(",
+			tokens: []Token{{Kind: FunctionName, Start: 0, Len: 2}, {Kind: OpenParen, Start: 2, Len: 1}},
+		},
+		{
+			name:   "parse case-insensitive operators",
+			input:  "~= ISTARTSWITH IENDSWITH ICONTAINS IIN IS NULL",
+			tokens: []Token{{Kind: EqualsFold, Start: 0, Len: 2}, {Kind: StartsWithFold, Start: 3, Len: 11}, {Kind: EndsWithFold, Start: 15, Len: 9}, {Kind: ContainsFold, Start: 25, Len: 9}, {Kind: InFold, Start: 35, Len: 3}, {Kind: Is, Start: 39, Len: 7}},
+		},
+		{
+			name:  "parse tilde",
+			input: "~",
+			err:   ErrUnsupportedCharacter{b: '~'},
+		},
+		{
+			name:  "parse is invalid",
+			input: "ISNULL",
+			err:   ErrInvalidKeyword{s: "ISNULL"},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,380 @@
+package express
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Matcher finds which of a large set of expressions match a payload.
+//
+// Each expression is analysed for equality, IN and numeric range constraints on selectors that must hold
+// for it to match. The constraints are indexed, in a hash table for equality and an interval tree for ranges,
+// and only the expressions whose constraints are satisfied by a payload, along with those that
+// have no such constraints, are fully evaluated.
+//
+// As expressions excluded by the index are not evaluated any error they would have returned is not reported.
+// A Matcher is immutable and safe for concurrent use.
+type Matcher struct {
+	ids       []string
+	exprs     []Expression
+	equality  map[string]map[any][]int
+	ranges    map[string]*intervalTree
+	unindexed []int
+}
+
+// NewMatcher builds a Matcher for the supplied expressions keyed by their ID.
+func NewMatcher(expressions map[string]Expression) *Matcher {
+	m := &Matcher{
+		ids:      make([]string, 0, len(expressions)),
+		exprs:    make([]Expression, 0, len(expressions)),
+		equality: make(map[string]map[any][]int),
+		ranges:   make(map[string]*intervalTree),
+	}
+
+	for id := range expressions {
+		m.ids = append(m.ids, id)
+	}
+	sort.Strings(m.ids)
+
+	intervals := make(map[string][]interval)
+	for i, id := range m.ids {
+		e := expressions[id]
+		m.exprs = append(m.exprs, e)
+
+		constraints, ok := indexConstraints(e)
+		if !ok {
+			m.unindexed = append(m.unindexed, i)
+			continue
+		}
+
+		for _, c := range constraints {
+			if c.values == nil {
+				c.interval.id = i
+				intervals[c.path] = append(intervals[c.path], c.interval)
+				continue
+			}
+
+			buckets := m.equality[c.path]
+			if buckets == nil {
+				buckets = make(map[any][]int)
+				m.equality[c.path] = buckets
+			}
+
+			for _, v := range c.values {
+				if ids := buckets[v]; len(ids) == 0 || ids[len(ids)-1] != i {
+					buckets[v] = append(ids, i)
+				}
+			}
+		}
+	}
+
+	for path, ivs := range intervals {
+		m.ranges[path] = newIntervalTree(ivs)
+	}
+	return m
+}
+
+// Match returns the IDs, in order, of the expressions that match the supplied JSON.
+//
+// An expression matches when it evaluates to any value other than `false` or null.
+// A candidate expression failing to evaluate does not stop the others, the matches being returned along with
+// an error joining an ErrRule for each expression that failed.
+func (m *Matcher) Match(src []byte) ([]string, error) {
+	doc := NewDocument(src)
+	env := newEnvironment(doc)
+
+	var ids []string
+	var errs []error
+	for _, i := range m.candidates(doc) {
+		value, err := env.eval(m.exprs[i])
+		if err != nil {
+			errs = append(errs, ErrRule{ID: m.ids[i], Err: err})
+			continue
+		}
+
+		if b, ok := value.(bool); (ok && !b) || value == nil {
+			continue
+		}
+		ids = append(ids, m.ids[i])
+	}
+	return ids, errors.Join(errs...)
+}
+
+// candidates returns the indexes, in order, of the expressions that could match the Document.
+func (m *Matcher) candidates(doc *Document) []int {
+	seen := make([]bool, len(m.exprs))
+	for _, i := range m.unindexed {
+		seen[i] = true
+	}
+
+	for path, buckets := range m.equality {
+		value, _ := doc.Get(path)
+		if isHashable(value) {
+			for _, i := range buckets[value] {
+				seen[i] = true
+			}
+		}
+	}
+
+	for path, tree := range m.ranges {
+		value, _ := doc.Get(path)
+		if f, ok := value.(float64); ok {
+			tree.stab(f, func(i int) {
+				seen[i] = true
+			})
+		}
+	}
+
+	var candidates []int
+	for i, ok := range seen {
+		if ok {
+			candidates = append(candidates, i)
+		}
+	}
+	return candidates
+}
+
+// constraint is a condition on the value of a selector, either being one of the
+// values or within the interval, that must hold for an expression to match.
+type constraint struct {
+	path     string
+	values   []any
+	interval interval
+}
+
+// indexConstraints returns the constraints of which at least one must hold for the expression to match,
+// returning false when the expression has no constraints that can be indexed.
+func indexConstraints(e Expression) ([]constraint, bool) {
+	switch t := e.(type) {
+	case and:
+		// either side must hold so prefer the one with the most selective constraints
+		left, lok := indexConstraints(t.left)
+		right, rok := indexConstraints(t.right)
+		switch {
+		case lok && rok:
+			if selectivity(right) < selectivity(left) {
+				return right, true
+			}
+			return left, true
+		case lok:
+			return left, true
+		default:
+			return right, rok
+		}
+	case or:
+		left, lok := indexConstraints(t.left)
+		right, rok := indexConstraints(t.right)
+		if !lok || !rok {
+			return nil, false
+		}
+		return append(left, right...), true
+	case eq:
+		path, value, _, ok := selectorOperands(t.left, t.right)
+		if !ok || !isHashable(value) || t.opts.Tolerance != 0 {
+			return nil, false
+		}
+		return []constraint{{path: path, values: []any{value}}}, true
+	case in:
+		sel, ok := t.left.(selectorPath)
+		if !ok || t.opts.Tolerance != 0 {
+			return nil, false
+		}
+
+		value, ok := literalValue(t.right)
+		arr, isArr := value.([]any)
+		if !ok || !isArr {
+			return nil, false
+		}
+
+		for _, v := range arr {
+			if !isHashable(v) {
+				return nil, false
+			}
+		}
+		return []constraint{{path: sel.s, values: append([]any{}, arr...)}}, true
+	case gt:
+		return rangeConstraint(t.left, t.right, false, true)
+	case gte:
+		return rangeConstraint(t.left, t.right, false, false)
+	case lt:
+		return rangeConstraint(t.left, t.right, true, true)
+	case lte:
+		return rangeConstraint(t.left, t.right, true, false)
+	case between:
+		sel, ok := t.value.(selectorPath)
+		if !ok {
+			return nil, false
+		}
+
+		lower, lok := literalValue(t.left)
+		upper, uok := literalValue(t.right)
+		lo, lfloat := lower.(float64)
+		hi, hfloat := upper.(float64)
+		if !lok || !uok || !lfloat || !hfloat {
+			return nil, false
+		}
+		return []constraint{{path: sel.s, interval: interval{lo: lo, hi: hi, loOpen: true, hiOpen: true}}}, true
+	default:
+		return nil, false
+	}
+}
+
+// rangeConstraint returns the interval of a comparison between a selector and a number,
+// less being true for `<` and `<=` comparisons and open for `<` and `>`.
+func rangeConstraint(left, right Expression, less, open bool) ([]constraint, bool) {
+	path, value, flipped, ok := selectorOperands(left, right)
+	f, isFloat := value.(float64)
+	if !ok || !isFloat || math.IsNaN(f) {
+		return nil, false
+	}
+
+	if flipped {
+		less = !less
+	}
+
+	iv := interval{lo: math.Inf(-1), hi: math.Inf(1)}
+	if less {
+		iv.hi, iv.hiOpen = f, open
+	} else {
+		iv.lo, iv.loOpen = f, open
+	}
+	return []constraint{{path: path, interval: iv}}, true
+}
+
+// selectorOperands returns the gjson path and constant value of a comparison between a selector
+// and a constant, flipped reports whether the constant is the left operand.
+func selectorOperands(left, right Expression) (path string, value any, flipped bool, ok bool) {
+	if sel, isSel := left.(selectorPath); isSel {
+		if value, ok = literalValue(right); ok {
+			return sel.s, value, false, true
+		}
+	}
+
+	if sel, isSel := right.(selectorPath); isSel {
+		if value, ok = literalValue(left); ok {
+			return sel.s, value, true, true
+		}
+	}
+	return "", nil, false, false
+}
+
+// selectivity ranks constraints, lower being more selective, equality being preferred over ranges.
+func selectivity(constraints []constraint) int {
+	var rank int
+	for _, c := range constraints {
+		if c.values == nil {
+			rank += 16
+		} else {
+			rank += len(c.values)
+		}
+	}
+	return rank
+}
+
+// isHashable returns true for the values which are indexed by equality.
+func isHashable(value any) bool {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// interval is a range of numbers, the bounds being excluded when open, constraining the expression id.
+type interval struct {
+	lo, hi         float64
+	loOpen, hiOpen bool
+	id             int
+}
+
+func (iv interval) contains(v float64) bool {
+	if v < iv.lo || (iv.loOpen && v == iv.lo) {
+		return false
+	}
+	return v < iv.hi || (!iv.hiOpen && v == iv.hi)
+}
+
+// intervalTree is a static centered interval tree.
+type intervalTree struct {
+	center      float64
+	left, right *intervalTree
+	// intervals overlapping the center sorted by ascending lower and descending upper bounds
+	byLo, byHi []interval
+}
+
+func newIntervalTree(ivs []interval) *intervalTree {
+	if len(ivs) == 0 {
+		return nil
+	}
+
+	endpoints := make([]float64, 0, len(ivs)*2)
+	for _, iv := range ivs {
+		for _, v := range []float64{iv.lo, iv.hi} {
+			if !math.IsInf(v, 0) {
+				endpoints = append(endpoints, v)
+			}
+		}
+	}
+
+	var t intervalTree
+	if len(endpoints) > 0 {
+		sort.Float64s(endpoints)
+		t.center = endpoints[len(endpoints)/2]
+	}
+
+	var left, right []interval
+	for _, iv := range ivs {
+		switch {
+		case iv.hi < t.center:
+			left = append(left, iv)
+		case iv.lo > t.center:
+			right = append(right, iv)
+		default:
+			t.byLo = append(t.byLo, iv)
+		}
+	}
+
+	t.byHi = append([]interval{}, t.byLo...)
+	sort.Slice(t.byLo, func(i, j int) bool { return t.byLo[i].lo < t.byLo[j].lo })
+	sort.Slice(t.byHi, func(i, j int) bool { return t.byHi[i].hi > t.byHi[j].hi })
+	t.left = newIntervalTree(left)
+	t.right = newIntervalTree(right)
+	return &t
+}
+
+// stab calls fn with the id of every interval containing v.
+func (t *intervalTree) stab(v float64, fn func(id int)) {
+	for t != nil {
+		switch {
+		case v < t.center:
+			for _, iv := range t.byLo {
+				if iv.lo > v {
+					break
+				}
+				if iv.contains(v) {
+					fn(iv.id)
+				}
+			}
+			t = t.left
+		case v > t.center:
+			for _, iv := range t.byHi {
+				if iv.hi < v {
+					break
+				}
+				if iv.contains(v) {
+					fn(iv.id)
+				}
+			}
+			t = t.right
+		default:
+			for _, iv := range t.byLo {
+				if iv.contains(v) {
+					fn(iv.id)
+				}
+			}
+			return
+		}
+	}
+}
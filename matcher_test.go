@@ -0,0 +1,206 @@
+package express
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	subscriptions := map[string]string{
+		"eu":         `.region == "eu"`,
+		"eu_types":   `.region == "eu" && .type IN ["a","b"]`,
+		"types":      `.type IN ["a","b"] && .count > 10`,
+		"flipped":    `"us" == .region`,
+		"either":     `.region == "us" || .type == "c"`,
+		"large":      `.count > 100`,
+		"small":      `10 >= .count`,
+		"between":    `.count BETWEEN 10 20`,
+		"null":       `.missing == NULL`,
+		"unindexed":  `!(.region == "cn")`,
+		"half_or":    `.region == "us" || !(.type == "c")`,
+		"ranged_and": `.count > 5 && .count < 8`,
+	}
+
+	parsed := make(map[string]Expression, len(subscriptions))
+	for id, subscription := range subscriptions {
+		ex, err := Parse([]byte(subscription))
+		require.NoError(t, err)
+		parsed[id] = ex
+	}
+	m := NewMatcher(parsed)
+
+	tests := []struct {
+		name       string
+		src        string
+		candidates []string
+		matches    []string
+	}{
+		{
+			name:       "eu type a",
+			src:        `{"region":"eu","type":"a","count":15,"name":"acme corp"}`,
+			candidates: []string{"between", "eu", "eu_types", "half_or", "null", "ranged_and", "types", "unindexed"},
+			matches:    []string{"between", "eu", "eu_types", "half_or", "null", "types", "unindexed"},
+		},
+		{
+			name:       "us type c",
+			src:        `{"region":"us","type":"c","count":6,"missing":1}`,
+			candidates: []string{"either", "flipped", "half_or", "ranged_and", "small", "unindexed"},
+			matches:    []string{"either", "flipped", "half_or", "ranged_and", "small", "unindexed"},
+		},
+		{
+			name:       "range boundaries",
+			src:        `{"region":"apac","count":10}`,
+			candidates: []string{"half_or", "null", "ranged_and", "small", "unindexed"},
+			matches:    []string{"half_or", "null", "small", "unindexed"},
+		},
+		{
+			name:       "non numeric count",
+			src:        `{"region":"apac","count":"101"}`,
+			candidates: []string{"half_or", "null", "unindexed"},
+			matches:    []string{"half_or", "null", "unindexed"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var candidates []string
+			for _, i := range m.candidates(NewDocument([]byte(tc.src))) {
+				candidates = append(candidates, m.ids[i])
+			}
+			assert.Equal(tc.candidates, candidates)
+
+			matches, err := m.Match([]byte(tc.src))
+			assert.NoError(err)
+			assert.Equal(tc.matches, matches)
+		})
+	}
+}
+
+func TestMatcherLinear(t *testing.T) {
+	assert := require.New(t)
+	r := rand.New(rand.NewSource(1))
+	regions := []string{"eu", "us", "apac"}
+
+	expressions := make(map[string]Expression)
+	for i := 0; i < 500; i++ {
+		var s string
+		switch i % 4 {
+		case 0:
+			s = fmt.Sprintf(`.region == "%s" && .count > %d`, regions[r.Intn(3)], r.Intn(100))
+		case 1:
+			s = fmt.Sprintf(`.count BETWEEN %d %d`, r.Intn(50), 50+r.Intn(50))
+		case 2:
+			s = fmt.Sprintf(`.count <= %d || .region IN ["%s"]`, r.Intn(100), regions[r.Intn(3)])
+		default:
+			s = fmt.Sprintf(`!(.count < %d)`, r.Intn(100))
+		}
+
+		ex, err := Parse([]byte(s))
+		assert.NoError(err)
+		expressions[fmt.Sprint(i)] = ex
+	}
+	m := NewMatcher(expressions)
+
+	for i := 0; i < 200; i++ {
+		src := []byte(fmt.Sprintf(`{"region":"%s","count":%d}`, regions[r.Intn(3)], r.Intn(110)))
+
+		var expected []string
+		for id, ex := range expressions {
+			value, err := ex.Calculate(src)
+			assert.NoError(err)
+			if value == true {
+				expected = append(expected, id)
+			}
+		}
+		sort.Strings(expected)
+
+		matches, err := m.Match(src)
+		assert.NoError(err)
+		assert.Equal(expected, matches, string(src))
+	}
+}
+
+func TestMatcherTolerance(t *testing.T) {
+	assert := require.New(t)
+	eq, err := ParseWithOptions([]byte(`.price == 10`), ParseOptions{Tolerance: 0.01})
+	assert.NoError(err)
+	in, err := ParseWithOptions([]byte(`.price IN [10, 20]`), ParseOptions{Tolerance: 0.01})
+	assert.NoError(err)
+
+	m := NewMatcher(map[string]Expression{"eq": eq, "in": in})
+	matches, err := m.Match([]byte(`{"price":9.999}`))
+	assert.NoError(err)
+	assert.Equal([]string{"eq", "in"}, matches)
+}
+
+func TestMatcherErrors(t *testing.T) {
+	assert := require.New(t)
+	expressions := make(map[string]Expression)
+	for id, s := range map[string]string{
+		"region": `.region == "eu"`,
+		"flag":   `!.flag`,
+		"other":  `!.other`,
+	} {
+		ex, err := Parse([]byte(s))
+		assert.NoError(err)
+		expressions[id] = ex
+	}
+	m := NewMatcher(expressions)
+
+	// every failing expression is reported without stopping the others
+	matches, err := m.Match([]byte(`{"region":"eu","flag":"yes","other":1}`))
+	assert.Equal([]string{"region"}, matches)
+
+	var ids []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ruleErr ErrRule
+		assert.True(errors.As(err, &ruleErr))
+		ids = append(ids, ruleErr.ID)
+	}
+	assert.Equal([]string{"flag", "other"}, ids)
+}
+
+func TestIntervalTree(t *testing.T) {
+	assert := require.New(t)
+	r := rand.New(rand.NewSource(1))
+
+	var intervals []interval
+	for i := 0; i < 300; i++ {
+		iv := interval{lo: float64(r.Intn(100)), hi: float64(r.Intn(100)), loOpen: r.Intn(2) == 0, hiOpen: r.Intn(2) == 0, id: i}
+		if iv.lo > iv.hi {
+			iv.lo, iv.hi = iv.hi, iv.lo
+		}
+
+		switch r.Intn(10) {
+		case 0:
+			iv.lo = math.Inf(-1)
+		case 1:
+			iv.hi = math.Inf(1)
+		}
+		intervals = append(intervals, iv)
+	}
+	tree := newIntervalTree(intervals)
+
+	for v := -1.0; v <= 101; v += 0.5 {
+		var expected, actual []int
+		for _, iv := range intervals {
+			if iv.contains(v) {
+				expected = append(expected, iv.id)
+			}
+		}
+
+		tree.stab(v, func(id int) {
+			actual = append(actual, id)
+		})
+		sort.Ints(actual)
+		assert.Equal(expected, actual, v)
+	}
+}
@@ -0,0 +1,116 @@
+package express
+
+import (
+	"math"
+	"strconv"
+)
+
+// finite returns the number, or an error if it is NaN or an infinity.
+func finite(f float64) (any, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, ErrNotFinite{Value: f}
+	}
+	return f, nil
+}
+
+// unary returns a function applying the math function to its number argument, null for null.
+func unary(fn func(float64) float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		f, err := numberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return finite(fn(f))
+	}
+}
+
+// pow returns the first argument raised to the power of the second.
+func pow(args []any) (any, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+
+	x, err := numberArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := numberArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return finite(math.Pow(x, y))
+}
+
+// logarithm returns the natural logarithm of the first argument, or its logarithm in the base of the optional second.
+func logarithm(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	x, err := numberArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 1 {
+		return finite(math.Log(x))
+	}
+
+	base, err := numberArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return finite(math.Log(x) / math.Log(base))
+}
+
+// round rounds the first argument to the optional number of decimal places, negative places rounding
+// to tens, hundreds and so on, and halves away from zero unless the third argument is "HALF_EVEN".
+func round(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	x, err := numberArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var places int
+	if len(args) > 1 {
+		f, ok := args[1].(float64)
+		if !ok || f != math.Trunc(f) || math.Abs(f) > 308 {
+			return nil, ErrInvalidArgument{Index: 1, Value: args[1], Expected: "integer number of decimal places"}
+		}
+		places = int(f)
+	}
+
+	halve := math.Round
+	if len(args) > 2 {
+		switch args[2] {
+		case "HALF_UP":
+		case "HALF_EVEN":
+			halve = math.RoundToEven
+		default:
+			return nil, ErrInvalidArgument{Index: 2, Value: args[2], Expected: `"HALF_UP" or "HALF_EVEN"`}
+		}
+	}
+
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return finite(x)
+	}
+
+	// the scaled number is rounded to 15 significant digits first so that values such as 1.005,
+	// stored as 1.00499999..., round as written
+	scale := math.Pow10(places)
+	scaled, err := strconv.ParseFloat(strconv.FormatFloat(x*scale, 'g', 15, 64), 64)
+	if err != nil || math.IsInf(scaled, 0) {
+		// too large to have any decimal places
+		return x, nil
+	}
+	return finite(halve(scaled) / scale)
+}
@@ -0,0 +1,196 @@
+package express
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMathFunctions(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "abs",
+			exp:      `ABS(.a - .b) < 0.01`,
+			src:      `{"a":1.004,"b":1.01}`,
+			expected: true,
+		},
+		{
+			name:     "abs null",
+			exp:      `ABS(.a)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "floor",
+			exp:      `FLOOR(.a)`,
+			src:      `{"a":-1.5}`,
+			expected: -2.0,
+		},
+		{
+			name:     "ceil",
+			exp:      `CEIL(.a)`,
+			src:      `{"a":1.2}`,
+			expected: 2.0,
+		},
+		{
+			name:     "sqrt",
+			exp:      `SQRT(.a)`,
+			src:      `{"a":16}`,
+			expected: 4.0,
+		},
+		{
+			name: "sqrt negative",
+			exp:  `SQRT(.a)`,
+			src:  `{"a":-1}`,
+			err:  ErrNotFinite{Function: "SQRT", Value: math.NaN(), Span: Span{Start: 0, End: 8}},
+		},
+		{
+			name:     "sqrt negative lenient",
+			exp:      `SQRT(.a)`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"a":-1}`,
+			expected: nil,
+		},
+		{
+			name:     "sqrt arguments",
+			exp:      `SQRT(.a, 2)`,
+			parseErr: errors.New("SQRT expects 1 argument, found 2"),
+		},
+		{
+			name:     "pow",
+			exp:      `POW(.a, 3)`,
+			src:      `{"a":2}`,
+			expected: 8.0,
+		},
+		{
+			name: "pow overflow",
+			exp:  `POW(.a, 400)`,
+			src:  `{"a":10}`,
+			err:  ErrNotFinite{Function: "POW", Value: math.Inf(1), Span: Span{Start: 0, End: 12}},
+		},
+		{
+			name:     "pow constant overflow",
+			exp:      `.a > POW(10, 400)`,
+			parseErr: ErrNotFinite{Function: "POW", Value: math.Inf(1)},
+		},
+		{
+			name:     "log",
+			exp:      `LOG(.a)`,
+			src:      `{"a":1}`,
+			expected: 0.0,
+		},
+		{
+			name:     "log base",
+			exp:      `LOG(.a, 2)`,
+			src:      `{"a":8}`,
+			expected: 3.0,
+		},
+		{
+			name: "log zero",
+			exp:  `LOG(.a)`,
+			src:  `{"a":0}`,
+			err:  ErrNotFinite{Function: "LOG", Value: math.Inf(-1), Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "round",
+			exp:      `ROUND(.a)`,
+			src:      `{"a":2.5}`,
+			expected: 3.0,
+		},
+		{
+			name:     "round negative",
+			exp:      `ROUND(.a)`,
+			src:      `{"a":-2.5}`,
+			expected: -3.0,
+		},
+		{
+			name:     "round places",
+			exp:      `ROUND(.amount, 2) == 10.5`,
+			src:      `{"amount":10.499}`,
+			expected: true,
+		},
+		{
+			name:     "round as written",
+			exp:      `ROUND(.a, 2)`,
+			src:      `{"a":1.005}`,
+			expected: 1.01,
+		},
+		{
+			name:     "round tens",
+			exp:      `ROUND(.a, -1)`,
+			src:      `{"a":1234}`,
+			expected: 1230.0,
+		},
+		{
+			name:     "round half even",
+			exp:      `ROUND(.a, 1, "HALF_EVEN")`,
+			src:      `{"a":0.25}`,
+			expected: 0.2,
+		},
+		{
+			name:     "round half up",
+			exp:      `ROUND(.a, 1, "HALF_UP")`,
+			src:      `{"a":0.25}`,
+			expected: 0.3,
+		},
+		{
+			name:     "round large",
+			exp:      `ROUND(.a, 2)`,
+			src:      `{"a":1e307}`,
+			expected: 1e307,
+		},
+		{
+			name:     "round null places",
+			exp:      `ROUND(.a, .places)`,
+			src:      `{"a":1.25}`,
+			expected: nil,
+		},
+		{
+			name: "round mode",
+			exp:  `ROUND(.a, 1, "UP")`,
+			src:  `{"a":1}`,
+			err:  ErrInvalidArgument{Function: "ROUND", Index: 2, Value: "UP", Expected: `"HALF_UP" or "HALF_EVEN"`, Span: Span{Start: 0, End: 18}},
+		},
+		{
+			name: "round places fraction",
+			exp:  `ROUND(.a, 1.5)`,
+			src:  `{"a":1}`,
+			err:  ErrInvalidArgument{Function: "ROUND", Index: 1, Value: 1.5, Expected: "integer number of decimal places", Span: Span{Start: 0, End: 14}},
+		},
+		{
+			name: "not a number",
+			exp:  `FLOOR(.a)`,
+			src:  `{"a":"1"}`,
+			err:  ErrInvalidArgument{Function: "FLOOR", Index: 0, Value: "1", Expected: "number", Span: Span{Start: 0, End: 9}},
+		},
+		{
+			name:     "min of arguments",
+			exp:      `MIN(.a, .b, 3)`,
+			src:      `{"a":5,"b":-1}`,
+			expected: -1.0,
+		},
+		{
+			name:     "max of arguments",
+			exp:      `MAX(.a, .b)`,
+			src:      `{"a":"x","b":"y"}`,
+			expected: "y",
+		},
+		{
+			name:     "max of arguments skips nulls",
+			exp:      `MAX(.a, .b)`,
+			src:      `{"b":2}`,
+			expected: 2.0,
+		},
+		{
+			name: "max of mixed arguments",
+			exp:  `MAX(.a, .b)`,
+			src:  `{"a":1,"b":"y"}`,
+			err:  ErrInvalidArgument{Function: "MAX", Index: 1, Value: "y", Expected: "number, string or datetime like the other arguments", Span: Span{Start: 0, End: 11}},
+		},
+		{
+			name:     "constant",
+			exp:      `ROUND(2.345, 2)`,
+			expected: 2.35,
+		},
+	})
+}
@@ -0,0 +1,197 @@
+package express
+
+import (
+	"regexp"
+)
+
+// ToMongo transpiles a parsed Expression into a MongoDB filter document.
+//
+// Comparisons must be between a selector and a constant, selectors are mapped to dotted field paths
+// and string operators such as CONTAINS and STARTSWITH are mapped to `$regex` filters.
+// As MongoDB applies filters to each element of an array field a string operator
+// also matches an array containing a matching string.
+//
+// Will return ErrUnsupportedTranspile for constructs that have no MongoDB equivalent.
+func ToMongo(e Expression) (map[string]any, error) {
+	switch t := e.(type) {
+	case and:
+		return mongoLogical("$and", t)
+	case or:
+		return mongoLogical("$or", t)
+	case not:
+		switch inner := t.value.(type) {
+		case eq:
+			return mongoComparison("$ne", inner.left, inner.right)
+		case exists:
+			return mongoExists(inner.s, false)
+		}
+
+		inner, err := ToMongo(t.value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$nor": []any{inner}}, nil
+	case selectorPath:
+		field, ok := fieldPath(t)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "selector path ." + t.s}
+		}
+		return map[string]any{field: map[string]any{"$eq": true}}, nil
+	case exists:
+		return mongoExists(t.s, true)
+	case eq:
+		return mongoComparison("$eq", t.left, t.right)
+	case gt:
+		return mongoComparison("$gt", t.left, t.right)
+	case gte:
+		return mongoComparison("$gte", t.left, t.right)
+	case lt:
+		return mongoComparison("$lt", t.left, t.right)
+	case lte:
+		return mongoComparison("$lte", t.left, t.right)
+	case between:
+		field, ok := fieldPath(t.value)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "BETWEEN on a non selector value"}
+		}
+
+		lower, lok := literalValue(t.left)
+		upper, uok := literalValue(t.right)
+		if !lok || !uok {
+			return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "BETWEEN with non constant bounds"}
+		}
+
+		// express BETWEEN is exclusive of both bounds
+		return map[string]any{field: map[string]any{"$gt": lower, "$lt": upper}}, nil
+	case in:
+		field, value, flipped, ok := fieldOperands(t.left, t.right)
+		if !ok {
+			return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "IN between a non selector and non constant"}
+		}
+
+		if flipped {
+			return map[string]any{field: map[string]any{"$elemMatch": map[string]any{"$eq": value}}}, nil
+		}
+
+		if _, ok := value.([]any); !ok {
+			return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "IN with a non array value"}
+		}
+		return map[string]any{field: map[string]any{"$in": value}}, nil
+	case contains:
+		return mongoContains(t.left, t.right)
+	case containsAny:
+		return mongoContainsEach("$or", "CONTAINS_ANY", t.left, t.right)
+	case containsAll:
+		return mongoContainsEach("$and", "CONTAINS_ALL", t.left, t.right)
+	case startsWith:
+		return mongoRegex("STARTSWITH", t.left, t.right, "^", "")
+	case endsWith:
+		return mongoRegex("ENDSWITH", t.left, t.right, "", "$")
+	default:
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: format(e)}
+	}
+}
+
+// mongoLogical flattens nested `&&` or `||` expressions into a single `$and` or `$or` filter.
+func mongoLogical(op string, e Expression) (map[string]any, error) {
+	operands := flattenLogical(e)
+	clauses := make([]any, 0, len(operands))
+	for _, operand := range operands {
+		filter, err := ToMongo(operand)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, filter)
+	}
+	return map[string]any{op: clauses}, nil
+}
+
+func mongoExists(path string, present bool) (map[string]any, error) {
+	field, ok := fieldPath(selectorPath{s: path})
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "selector path ." + path}
+	}
+	return map[string]any{field: map[string]any{"$exists": present}}, nil
+}
+
+func mongoComparison(op string, left, right Expression) (map[string]any, error) {
+	field, value, flipped, ok := fieldOperands(left, right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "comparison between a non selector and non constant"}
+	}
+
+	if flipped {
+		op = flippedMongoOps[op]
+	}
+	return map[string]any{field: map[string]any{op: value}}, nil
+}
+
+var flippedMongoOps = map[string]string{
+	"$eq":  "$eq",
+	"$ne":  "$ne",
+	"$gt":  "$lt",
+	"$gte": "$lte",
+	"$lt":  "$gt",
+	"$lte": "$gte",
+}
+
+func mongoContains(left, right Expression) (map[string]any, error) {
+	field, ok := fieldPath(left)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "CONTAINS on a non selector value"}
+	}
+
+	value, ok := literalValue(right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: "CONTAINS with a non constant value"}
+	}
+
+	if s, ok := value.(string); ok {
+		return map[string]any{field: map[string]any{"$regex": regexp.QuoteMeta(s)}}, nil
+	}
+	return map[string]any{field: map[string]any{"$elemMatch": map[string]any{"$eq": value}}}, nil
+}
+
+func mongoContainsEach(op, name string, left, right Expression) (map[string]any, error) {
+	value, ok := literalValue(right)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: name + " with a non constant value"}
+	}
+
+	arr, ok := value.([]any)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: name + " with a non array value"}
+	}
+
+	// MongoDB rejects an empty `$or` or `$and`, so an empty array matches nothing or everything instead
+	if len(arr) == 0 {
+		if op == "$or" {
+			return map[string]any{"$expr": false}, nil
+		}
+		return map[string]any{}, nil
+	}
+
+	clauses := make([]any, 0, len(arr))
+	for _, v := range arr {
+		filter, err := mongoContains(left, coercedConstant{value: v})
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, filter)
+	}
+	return map[string]any{op: clauses}, nil
+}
+
+func mongoRegex(name string, left, right Expression, prefix, suffix string) (map[string]any, error) {
+	field, ok := fieldPath(left)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: name + " on a non selector value"}
+	}
+
+	value, _ := literalValue(right)
+	s, ok := value.(string)
+	if !ok {
+		return nil, ErrUnsupportedTranspile{target: "MongoDB", s: name + " with a non string constant"}
+	}
+	return map[string]any{field: map[string]any{"$regex": prefix + regexp.QuoteMeta(s) + suffix}}, nil
+}
@@ -0,0 +1,140 @@
+package express
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMongo(t *testing.T) {
+	assert := require.New(t)
+	tests := []struct {
+		name     string
+		exp      string
+		expected map[string]any
+		err      error
+	}{
+		{
+			name:     "eq",
+			exp:      `.a.b == "x"`,
+			expected: map[string]any{"a.b": map[string]any{"$eq": "x"}},
+		},
+		{
+			name:     "not eq",
+			exp:      `.a != NULL`,
+			expected: map[string]any{"a": map[string]any{"$ne": nil}},
+		},
+		{
+			name:     "flipped gt",
+			exp:      `20 > .employees`,
+			expected: map[string]any{"employees": map[string]any{"$lt": float64(20)}},
+		},
+		{
+			name: "and flattened",
+			exp:  `.a == 1 && .b >= 2 && .c < 3`,
+			expected: map[string]any{"$and": []any{
+				map[string]any{"a": map[string]any{"$eq": float64(1)}},
+				map[string]any{"b": map[string]any{"$gte": float64(2)}},
+				map[string]any{"c": map[string]any{"$lt": float64(3)}},
+			}},
+		},
+		{
+			name: "or not",
+			exp:  `.a || !(.b STARTSWITH "x.")`,
+			expected: map[string]any{"$or": []any{
+				map[string]any{"a": map[string]any{"$eq": true}},
+				map[string]any{"$nor": []any{map[string]any{"b": map[string]any{"$regex": `^x\.`}}}},
+			}},
+		},
+		{
+			name:     "in",
+			exp:      `.region IN ["eu", "us"]`,
+			expected: map[string]any{"region": map[string]any{"$in": []any{"eu", "us"}}},
+		},
+		{
+			name:     "value in selector",
+			exp:      `"a" IN .tags`,
+			expected: map[string]any{"tags": map[string]any{"$elemMatch": map[string]any{"$eq": "a"}}},
+		},
+		{
+			name: "contains any",
+			exp:  `.name CONTAINS_ANY ["a+", "b"]`,
+			expected: map[string]any{"$or": []any{
+				map[string]any{"name": map[string]any{"$regex": `a\+`}},
+				map[string]any{"name": map[string]any{"$regex": "b"}},
+			}},
+		},
+		{
+			name:     "contains any empty",
+			exp:      `.name CONTAINS_ANY []`,
+			expected: map[string]any{"$expr": false},
+		},
+		{
+			name:     "contains all empty",
+			exp:      `.name CONTAINS_ALL []`,
+			expected: map[string]any{},
+		},
+		{
+			name:     "exists",
+			exp:      `EXISTS .a.b`,
+			expected: map[string]any{"a.b": map[string]any{"$exists": true}},
+		},
+		{
+			name:     "missing",
+			exp:      `.a IS MISSING`,
+			expected: map[string]any{"a": map[string]any{"$exists": false}},
+		},
+		{
+			name:     "endswith",
+			exp:      `.name ENDSWITH "am"`,
+			expected: map[string]any{"name": map[string]any{"$regex": "am$"}},
+		},
+		{
+			name: "between datetime",
+			exp:  `COERCE .created _datetime_ BETWEEN COERCE "2022-01-01" _datetime_ COERCE "2022-01-30" _datetime_`,
+			expected: map[string]any{"created": map[string]any{
+				"$gt": time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				"$lt": time.Date(2022, 1, 30, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name: "selector compared to selector",
+			exp:  `.a == .b`,
+			err:  ErrUnsupportedTranspile{target: "MongoDB", s: "comparison between a non selector and non constant"},
+		},
+		{
+			name: "arithmetic",
+			exp:  `.a + 1 > 2`,
+			err:  ErrUnsupportedTranspile{target: "MongoDB", s: "comparison between a non selector and non constant"},
+		},
+		{
+			name: "gjson query",
+			exp:  `.items.#.active`,
+			err:  ErrUnsupportedTranspile{target: "MongoDB", s: "selector path .items.#.active"},
+		},
+		{
+			name: "function",
+			exp:  `LEN(.a)`,
+			err:  ErrUnsupportedTranspile{target: "MongoDB", s: "LEN(.a)"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			got, err := ToMongo(ex)
+			if tc.err != nil {
+				assert.Equal(tc.err, err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package express
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+var (
+	_ Expression = (*hasKey)(nil)
+	_ Expression = (*member)(nil)
+)
+
+// member is a `.field` member of an object or element of an array, null when not found.
+type member struct {
+	value Expression
+	path  string
+	span  Span
+	opts  ParseOptions
+}
+
+func (m member) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(m)
+}
+
+func (m member) eval(env *environment) (any, error) {
+	value, err := env.eval(m.value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value.(type) {
+	case nil, map[string]any, []any:
+		result, _ := lookup(reflect.ValueOf(value), m.path)
+		return result, nil
+	default:
+		return m.opts.mismatch(nil, newUnaryTypeError(m.span, ".", value))
+	}
+}
+
+// hasKey tests whether an object has a key or, for HAS_ANY_KEYS, any of an array of keys.
+type hasKey struct {
+	left   Expression
+	right  Expression
+	anyKey bool
+	span   Span
+	opts   ParseOptions
+}
+
+func (h hasKey) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(h)
+}
+
+func (h hasKey) eval(env *environment) (any, error) {
+	left, err := env.eval(h.left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := env.eval(h.right)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.opts.unknown(left, right) {
+		return nil, nil
+	}
+
+	obj, ok := left.(map[string]any)
+	if !ok {
+		return h.opts.mismatch(false, newTypeError(h.span, h.op(), left, right))
+	}
+
+	if !h.anyKey {
+		key, ok := right.(string)
+		if !ok {
+			return h.opts.mismatch(false, newTypeError(h.span, h.op(), left, right))
+		}
+		_, found := obj[key]
+		return found, nil
+	}
+
+	arr, ok := right.([]any)
+	if !ok {
+		return h.opts.mismatch(false, newTypeError(h.span, h.op(), left, right))
+	}
+
+	for _, v := range arr {
+		if err := env.iterate(); err != nil {
+			return nil, err
+		}
+
+		key, ok := v.(string)
+		if !ok {
+			return h.opts.mismatch(false, newTypeError(h.span, h.op(), left, right))
+		}
+		if _, found := obj[key]; found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (h hasKey) op() string {
+	if h.anyKey {
+		return "HAS_ANY_KEYS"
+	}
+	return "HAS_KEY"
+}
+
+// objectArg returns the argument at the index as an object.
+func objectArg(args []any, i int) (map[string]any, error) {
+	obj, ok := args[i].(map[string]any)
+	if !ok {
+		return nil, ErrInvalidArgument{Index: i, Value: args[i], Expected: "object"}
+	}
+	return obj, nil
+}
+
+// keys returns the sorted keys of an object.
+func keys(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	obj, err := objectArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]any, 0, len(obj))
+	for _, k := range slices.Sorted(maps.Keys(obj)) {
+		sorted = append(sorted, k)
+	}
+	return sorted, nil
+}
+
+// values returns the values of an object in the order of its sorted keys.
+func values(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	obj, err := objectArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]any, 0, len(obj))
+	for _, k := range slices.Sorted(maps.Keys(obj)) {
+		sorted = append(sorted, obj[k])
+	}
+	return sorted, nil
+}
@@ -0,0 +1,140 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjects(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "has key",
+			exp:      `.labels HAS_KEY "env"`,
+			src:      `{"labels":{"env":"prod"}}`,
+			expected: true,
+		},
+		{
+			name:     "has null key",
+			exp:      `.labels HAS_KEY "env"`,
+			src:      `{"labels":{"env":null}}`,
+			expected: true,
+		},
+		{
+			name:     "has no key",
+			exp:      `.labels HAS_KEY "env"`,
+			src:      `{"labels":{"team":"a"}}`,
+			expected: false,
+		},
+		{
+			name:     "has key selector",
+			exp:      `.labels HAS_KEY .key`,
+			src:      `{"labels":{"team":"a"},"key":"team"}`,
+			expected: true,
+		},
+		{
+			name: "has key not object",
+			exp:  `.labels HAS_KEY "env"`,
+			src:  `{"labels":["env"]}`,
+			err:  ErrUnsupportedTypeComparison{Op: "HAS_KEY", Left: []any{"env"}, Right: "env", LeftType: "array", RightType: "string", Span: Span{Start: 0, End: 21}},
+		},
+		{
+			name:     "has key not string",
+			exp:      `.labels HAS_KEY 1`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"labels":{}}`,
+			expected: false,
+		},
+		{
+			name:     "has any keys",
+			exp:      `.labels HAS_ANY_KEYS ["env", "team"]`,
+			src:      `{"labels":{"team":"a"}}`,
+			expected: true,
+		},
+		{
+			name:     "has none of keys",
+			exp:      `.labels HAS_ANY_KEYS ["env", "team"]`,
+			src:      `{"labels":{"owner":"a"}}`,
+			expected: false,
+		},
+		{
+			name: "has any keys not strings",
+			exp:  `.labels HAS_ANY_KEYS [1]`,
+			src:  `{"labels":{}}`,
+			err:  ErrUnsupportedTypeComparison{Op: "HAS_ANY_KEYS", Left: map[string]any{}, Right: []any{1.0}, LeftType: "object", RightType: "array", Span: Span{Start: 0, End: 24}},
+		},
+		{
+			name:     "keys",
+			exp:      `KEYS(.labels)`,
+			src:      `{"labels":{"team":"a","env":"prod"}}`,
+			expected: []any{"env", "team"},
+		},
+		{
+			name:     "keys empty",
+			exp:      `KEYS(.labels)`,
+			src:      `{"labels":{}}`,
+			expected: []any{},
+		},
+		{
+			name:     "keys missing",
+			exp:      `KEYS(.labels)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "keys subset",
+			exp:      `KEYS(.labels) SUBSET_OF ["env", "team", "owner"]`,
+			src:      `{"labels":{"team":"a","env":"prod"}}`,
+			expected: true,
+		},
+		{
+			name:     "values",
+			exp:      `VALUES(.labels)`,
+			src:      `{"labels":{"team":"a","env":{"x":1}}}`,
+			expected: []any{map[string]any{"x": 1.0}, "a"},
+		},
+		{
+			name: "values not object",
+			exp:  `VALUES(.labels)`,
+			src:  `{"labels":"a"}`,
+			err:  ErrInvalidArgument{Function: "VALUES", Index: 0, Value: "a", Expected: "object", Span: Span{Start: 0, End: 15}},
+		},
+		{
+			name:     "len",
+			exp:      `LEN(.labels)`,
+			src:      `{"labels":{"team":"a","env":"prod"}}`,
+			expected: 2.0,
+		},
+		{
+			name:     "equal",
+			exp:      `.a == .b`,
+			src:      `{"a":{"x":1,"y":[1,{"z":null}]},"b":{"y":[1,{"z":null}],"x":1}}`,
+			expected: true,
+		},
+		{
+			name:     "not equal",
+			exp:      `.a != .b`,
+			src:      `{"a":{"x":1},"b":{"x":1,"y":2}}`,
+			expected: true,
+		},
+		{
+			name:     "in objects",
+			exp:      `.a IN .b`,
+			src:      `{"a":{"x":1},"b":[{"x":2},{"x":1}]}`,
+			expected: true,
+		},
+	})
+}
+
+func TestObjectsFormat(t *testing.T) {
+	assert := require.New(t)
+	for _, exp := range []string{
+		`.labels HAS_KEY "env"`,
+		`.labels HAS_ANY_KEYS ["env", "team"]`,
+		`KEYS(.labels) SUBSET_OF VALUES(.names)`,
+	} {
+		ex, err := Parse([]byte(exp))
+		assert.NoError(err)
+		assert.Equal(exp, format(ex))
+	}
+}
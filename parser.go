@@ -16,7 +16,7 @@ import (
 	"github.com/pchchv/extender/resultext"
 	"github.com/pchchv/extender/syncext"
 	"github.com/pchchv/goitertools"
-	"github.com/tidwall/gjson"
+	"golang.org/x/text/language"
 )
 
 var (
@@ -52,12 +52,15 @@ var (
 	_ Expression = (*coerceUppercase)(nil)
 	_ Expression = (*coerceLowercase)(nil)
 	_ Expression = (*coercedConstant)(nil)
+	_ Expression = (*exists)(nil)
+	_ Expression = (*isNull)(nil)
+	_ Expression = (*isType)(nil)
 	// Coercions is a `map` of all coercions guarded by a Mutex for use allowing registration, removal or even replacing of existing coercions.
 	Coercions = syncext.NewRWMutex(map[string]func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error){
-		"_datetime_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_datetime_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceDateTime{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -66,10 +69,10 @@ var (
 				return false, expression, nil
 			}
 		},
-		"_lowercase_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_lowercase_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceLowercase{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -78,10 +81,10 @@ var (
 				return false, expression, nil
 			}
 		},
-		"_string_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_string_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceString{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -90,10 +93,10 @@ var (
 				return false, expression, nil
 			}
 		},
-		"_number_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_number_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceNumber{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -102,10 +105,10 @@ var (
 				return false, expression, nil
 			}
 		},
-		"_uppercase_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_uppercase_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceUppercase{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -114,10 +117,10 @@ var (
 				return false, expression, nil
 			}
 		},
-		"_title_": func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		"_title_": func(p *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
 			expression = coerceTitle{value: expression}
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -221,7 +224,7 @@ var (
 			}
 
 			if constEligible {
-				value, err := expression.Calculate([]byte{})
+				value, err := p.fold(expression)
 				if err != nil {
 					return false, nil, err
 				}
@@ -246,33 +249,197 @@ type Expression interface {
 type Parser struct {
 	Exp       []byte
 	Tokenizer goitertools.PeekableIterator[resultext.Result[Token, error]]
+	tokens    *tokenStream
+	// closedParen is true when the last expression parsed was ended by a closing parenthesis
+	// and closedComma when it was ended by the comma separating function arguments.
+	closedParen, closedComma bool
+	// inArgs is true when parsing the arguments of a function call.
+	inArgs bool
+	// depth is the number of open parentheses.
+	depth int
+	// recovering is true when parsing continues past errors, recording them in diagnostics.
+	recovering  bool
+	diagnostics []ParseError
+	opts        ParseOptions
 }
 
 // Parse lex's' the provided expression and returns an Expression to be used/applied to data.
-func Parse(expression []byte) (result Expression, err error) {
-	p := Parser{
-		Exp:       expression,
-		Tokenizer: goitertools.Iter(NewTokenizer(expression)).Peekable(),
-	}
+//
+// Will return a ParseError locating the error within the expression if it cannot be parsed.
+func Parse(expression []byte) (Expression, error) {
+	return ParseWithOptions(expression, ParseOptions{})
+}
+
+// Mode determines how operators handle values they do not support.
+type Mode uint8
+
+const (
+	// Default returns errors for operands of unsupported types, with the exception of `&&` treating a non-boolean
+	// left hand side as `false`, null arithmetic operands being ignored, BETWEEN a null being `false` and
+	// `_datetime_` and `_substr_` returning null for unparsable dates and out of range indexes.
+	Default Mode = iota
+	// Strict returns errors for all operands of unsupported types, unparsable dates and out of range indexes.
+	Strict
+	// Lenient never returns errors for operands of unsupported types, predicates evaluating to `false`
+	// and arithmetic and coercions to null instead.
+	Lenient
+)
 
-	if result, err = p.parseExpression(); err != nil {
-		return nil, err
+// ParseOptions configures the semantics of an expression parsed by ParseWithOptions.
+type ParseOptions struct {
+	// Mode determines how operators handle values they do not support.
+	Mode Mode
+	// Collation is the BCP 47 language tag, such as `de` or `sv`, of the locale whose ordering strings are compared
+	// with by `>`, `>=`, `<`, `<=` and BETWEEN, strings being compared bytewise by default.
+	Collation string
+	// Tolerance is the largest difference between numbers that are still equal when compared by `==`, `!=`, IN,
+	// CONTAINS, CONTAINS_ANY, CONTAINS_ALL, the set operators, DISTINCT and INDEX_OF, numbers being equal only
+	// when identical by default.
+	Tolerance float64
+	// ThreeValuedLogic enables SQL NULL semantics, where null is UNKNOWN: operators with a null operand
+	// return null and `&&`, `||` and `!` follow three-valued logic, `null && false` being `false`,
+	// `null || true` being `true` and otherwise null.
+	ThreeValuedLogic bool
+}
+
+// ParseWithOptions parses the expression the same as Parse, applying the options when it is calculated.
+func ParseWithOptions(expression []byte, opts ParseOptions) (Expression, error) {
+	if opts.Collation != "" {
+		if _, err := language.Parse(opts.Collation); err != nil {
+			return nil, fmt.Errorf("invalid collation '%s': %w", opts.Collation, err)
+		}
+	}
+
+	p := newParser(expression)
+	p.opts = opts
+	result, err := p.parseExpression()
+	if err != nil {
+		return nil, newParseError(expression, p.tokens.offset(), err)
 	} else if result == nil {
-		err = errors.New("no expression results found")
+		return nil, newParseError(expression, len(expression), errors.New("no expression results found"))
+	}
+
+	if opts != (ParseOptions{}) {
+		result = withOptions(result, opts)
+	}
+	return result, nil
+}
+
+// mismatch returns the error for operands of unsupported types or, in the Lenient mode, the fallback value instead.
+func (o ParseOptions) mismatch(fallback any, err error) (any, error) {
+	if o.Mode == Lenient {
+		return fallback, nil
+	}
+	return nil, err
+}
+
+// unknown returns if the values are UNKNOWN under three-valued logic, being when any of them are null.
+func (o ParseOptions) unknown(values ...any) bool {
+	if !o.ThreeValuedLogic {
+		return false
+	}
+
+	for _, v := range values {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func newParser(expression []byte) *Parser {
+	tokenizer := NewTokenizer(expression)
+	p := &Parser{
+		Exp: expression,
+		tokens: &tokenStream{
+			PeekableIterator: goitertools.Iter(tokenizer).Peekable(),
+			tokenizer:        tokenizer,
+		},
+	}
+	p.Tokenizer = p.tokens
+	return p
+}
+
+// tokenStream is the token iterator of a Parser, which records the tokens
+// consumed so parse errors can be located within the expression.
+type tokenStream struct {
+	goitertools.PeekableIterator[resultext.Result[Token, error]]
+	tokenizer *Tokenizer
+	last      optionext.Option[Token]
+	lexErr    optionext.Option[uint32]
+	exhausted bool
+	// end and prevEnd are the end offsets of the last and second to last tokens consumed.
+	end, prevEnd uint32
+}
+
+func (s *tokenStream) Next() optionext.Option[resultext.Result[Token, error]] {
+	next := s.PeekableIterator.Next()
+	switch {
+	case next.IsNone():
+		s.exhausted = true
+	case next.Unwrap().IsErr():
+		// the Tokenizer does not advance past an error
+		s.lexErr = optionext.Some(s.tokenizer.pos)
+	default:
+		token := next.Unwrap().Unwrap()
+		s.last = optionext.Some(token)
+		s.prevEnd, s.end = s.end, token.Start+uint32(token.Len)
+	}
+	return next
+}
+
+// offset returns the byte offset of the error that stopped parsing, being the position of a lexing error,
+// the end of the expression if it ended unexpectedly or otherwise the last token consumed.
+func (s *tokenStream) offset() int {
+	switch {
+	case s.lexErr.IsSome():
+		return int(s.lexErr.Unwrap())
+	case s.exhausted:
+		return int(s.tokenizer.pos)
+	case s.last.IsSome():
+		return int(s.last.Unwrap().Start)
+	default:
+		return 0
+	}
+}
+
+// Span is the location of a subexpression within the parsed expression as byte offsets.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Text returns the subexpression located by the Span within the parsed expression.
+func (s Span) Text(expression []byte) string {
+	if s.Start < 0 || s.End > len(expression) || s.Start > s.End {
+		return ""
+	}
+	return string(expression[s.Start:s.End])
+}
+
+// location describes the Span for error messages, being empty for a zero Span.
+func (s Span) location() string {
+	if s == (Span{}) {
+		return ""
 	}
+	return fmt.Sprintf(" at [%d:%d]", s.Start, s.End)
+}
+
+// fold calculates the constant expression with the options being parsed with.
+func (p *Parser) fold(expression Expression) (any, error) {
+	return withOptions(expression, p.opts).Calculate([]byte{})
+}
 
-	return
+// text returns the source text of the token.
+func (p *Parser) text(token Token) string {
+	start := int(token.Start)
+	return string(p.Exp[start : start+int(token.Len)])
 }
 
 func (p *Parser) parseOperation(token Token, current Expression) (Expression, error) {
 	switch token.Kind {
 	case Add:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -282,12 +449,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Subtract:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -297,12 +459,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Multiply:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -312,12 +469,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Divide:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -326,12 +478,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Equals:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -341,12 +488,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Gt:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -356,12 +498,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Gte:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -371,12 +508,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Lt:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -386,12 +518,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Lte:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -407,6 +534,11 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 				return nil, errors.New("expression after or '||' ends unexpectedly")
 			}
 			return nil, err
+		} else if right == nil {
+			if !p.recovering {
+				return nil, errors.New("expression after or '||' ends unexpectedly")
+			}
+			right = p.recover(errors.New("expression after or '||' ends unexpectedly"))
 		}
 		return or{
 			left:  current,
@@ -416,21 +548,21 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 		right, err := p.parseExpression()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				return nil, errors.New("expression after or '&&' ends unexpectedly")
+				return nil, errors.New("expression after and '&&' ends unexpectedly")
 			}
 			return nil, err
+		} else if right == nil {
+			if !p.recovering {
+				return nil, errors.New("expression after and '&&' ends unexpectedly")
+			}
+			right = p.recover(errors.New("expression after and '&&' ends unexpectedly"))
 		}
 		return and{
 			left:  current,
 			right: right,
 		}, nil
 	case StartsWith:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -440,12 +572,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case EndsWith:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -455,12 +582,7 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case In:
-		nextToken, err := p.nextOperatorToken(token)
-		if err != nil {
-			return nil, err
-		}
-
-		right, err := p.parseValue(nextToken)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
@@ -470,71 +592,120 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 			right: right,
 		}, nil
 	case Contains:
-		nextToken, err := p.nextOperatorToken(token)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := p.parseValue(nextToken)
+		return contains{
+			left:  current,
+			right: right,
+		}, nil
+	case ContainsAny:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		return contains{
+		return containsAny{
 			left:  current,
 			right: right,
 		}, nil
-	case ContainsAny:
-		nextToken, err := p.nextOperatorToken(token)
+	case HasKey, HasAnyKeys:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := p.parseValue(nextToken)
+		return hasKey{
+			left:   current,
+			right:  right,
+			anyKey: token.Kind == HasAnyKeys,
+		}, nil
+	case Intersects, SubsetOf, SupersetOf, Disjoint, SetEquals:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		return containsAny{
+		return setOperation{
+			op:    token.Kind,
 			left:  current,
 			right: right,
 		}, nil
 	case ContainsAll:
-		nextToken, err := p.nextOperatorToken(token)
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := p.parseValue(nextToken)
+		return containsAll{
+			left:  current,
+			right: right,
+		}, nil
+	case EqualsFold:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		return containsAll{
-			left:  current,
-			right: right,
+		return eq{
+			left:  fold{value: current},
+			right: fold{value: right},
 		}, nil
-	case Between:
-		lhsToken, err := p.nextOperatorToken(token)
+	case StartsWithFold:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		left, err := p.parseValue(lhsToken)
+		return startsWith{
+			left:  fold{value: current},
+			right: fold{value: right},
+		}, nil
+	case EndsWithFold:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		rhsToken, err := p.nextOperatorToken(token)
+		return endsWith{
+			left:  fold{value: current},
+			right: fold{value: right},
+		}, nil
+	case InFold:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
-		right, err := p.parseValue(rhsToken)
+		return in{
+			left:  fold{value: current},
+			right: fold{value: right},
+		}, nil
+	case ContainsFold:
+		right, err := p.parseOperand(token)
 		if err != nil {
 			return nil, err
 		}
 
+		return contains{
+			left:  fold{value: current},
+			right: fold{value: right},
+		}, nil
+	case Between:
+		left, err := p.parseOperand(token)
+		if err != nil {
+			return nil, err
+		}
+
+		right := left
+		if _, ok := left.(invalid); !ok {
+			if right, err = p.parseOperand(token); err != nil {
+				return nil, err
+			}
+		}
+
 		return between{
 			left:  left,
 			right: right,
@@ -554,14 +725,53 @@ func (p *Parser) parseOperation(token Token, current Expression) (Expression, er
 		return not{
 			value: value,
 		}, nil
+	case Is:
+		// IS [NOT] NULL|MISSING|<TYPE>
+		keywords := strings.Fields(p.text(token))
+		negated := len(keywords) == 3
+
+		var test Expression
+		switch keyword := keywords[len(keywords)-1]; keyword {
+		case "NULL":
+			test = isNull{value: current}
+		case "MISSING":
+			selector, ok := current.(selectorPath)
+			if !ok {
+				return nil, errors.New("IS MISSING requires a selector path")
+			}
+			test = exists{s: selector.s}
+			negated = !negated
+		default:
+			kind, found := typeTests[keyword]
+			if !found {
+				return nil, fmt.Errorf("invalid IS test: `%s`", keyword)
+			}
+			test = isType{value: current, kind: kind}
+		}
+
+		if negated {
+			return not{value: test}, nil
+		}
+		return test, nil
 	case CloseBracket:
 		return current, nil
+	case SelectorPath:
+		return nil, fmt.Errorf("invalid operation: `%s`, a member access must follow its value without whitespace", p.text(token))
 	default:
-		return nil, fmt.Errorf("invalid operation: %v", token)
+		return nil, fmt.Errorf("invalid operation: `%s`", p.text(token))
 	}
 }
 
 func (p *Parser) parseValue(token Token) (Expression, error) {
+	value, err := p.parsePrimary(token)
+	if err != nil {
+		return nil, err
+	}
+	return p.parsePostfix(token.Start, value)
+}
+
+// parsePrimary parses the value starting at the token, without any postfix operators following it.
+func (p *Parser) parsePrimary(token Token) (Expression, error) {
 	switch token.Kind {
 	case OpenBracket:
 		arr := make([]Expression, 0, 2)
@@ -569,11 +779,19 @@ func (p *Parser) parseValue(token Token) (Expression, error) {
 		for {
 			next := p.Tokenizer.Next()
 			if next.IsNone() {
+				if p.recovering {
+					p.recover(errors.New("unclosed Array '['"))
+					break FOR
+				}
 				return nil, errors.New("unclosed Array '['")
 			}
 
 			result := next.Unwrap()
 			if result.IsErr() {
+				if p.recovering {
+					p.recover(result.Err())
+					continue
+				}
 				return nil, result.Err()
 			}
 
@@ -586,7 +804,10 @@ func (p *Parser) parseValue(token Token) (Expression, error) {
 			default:
 				value, err := p.parseValue(token)
 				if err != nil {
-					return nil, err
+					if !p.recovering {
+						return nil, err
+					}
+					value = p.recover(err)
 				}
 				arr = append(arr, value)
 			}
@@ -597,12 +818,19 @@ func (p *Parser) parseValue(token Token) (Expression, error) {
 		}
 		return array{vec: arr}, nil
 	case OpenParen:
+		inArgs := p.inArgs
+		p.depth++
+		p.inArgs = false
 		expression, err := p.parseExpression()
+		p.depth--
+		p.inArgs = inArgs
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				return nil, errors.New("expression after open parenthesis '(' ends unexpectedly")
 			}
 			return nil, err
+		} else if expression == nil {
+			return nil, errors.New("expression after open parenthesis '(' ends unexpectedly")
 		}
 		return expression, nil
 	case SelectorPath:
@@ -675,6 +903,7 @@ func (p *Parser) parseValue(token Token) (Expression, error) {
 				if err != nil {
 					return nil, err
 				}
+				expression = withSpan(expression, Span{Start: int(token.Start), End: int(p.tokens.end)})
 			} else {
 				return nil, fmt.Errorf("invalid COERCE data type '%s'", identifier)
 			}
@@ -688,52 +917,285 @@ func (p *Parser) parseValue(token Token) (Expression, error) {
 		}
 		return expression, nil
 	case Not:
+		value, err := p.parseOperand(token)
+		if err != nil {
+			return nil, err
+		}
+		return not{value: value, span: Span{Start: int(token.Start), End: int(p.tokens.end)}}, nil
+	case Exists:
+		// EXISTS <selector>
 		nextToken, err := p.nextOperatorToken(token)
 		if err != nil {
 			return nil, err
 		}
 
-		value, err := p.parseValue(nextToken)
+		if nextToken.Kind != SelectorPath {
+			return nil, fmt.Errorf("EXISTS requires a selector path, found instead: `%s`", p.text(nextToken))
+		}
+		start := int(nextToken.Start)
+		return exists{
+			s: string(p.Exp[start+1 : start+int(nextToken.Len)]),
+		}, nil
+	case FunctionName:
+		// NAME(<expression>, ...)
+		name := p.text(token)
+		guard := Functions.RLock()
+		fn, found := guard.T[name]
+		guard.RUnlock()
+		if !found {
+			return nil, fmt.Errorf("unknown function '%s'", name)
+		}
+
+		_ = p.Tokenizer.Next() // consume the opening parenthesis lexed with the name
+		args, err := p.parseArguments(name)
 		if err != nil {
 			return nil, err
 		}
-		return not{value: value}, nil
+
+		if len(args) < fn.MinArgs || (fn.MaxArgs >= 0 && len(args) > fn.MaxArgs) {
+			return nil, fmt.Errorf("%s expects %s, found %d", name, fn.arity(), len(args))
+		}
+
+		expression := call{
+			name: name,
+			fn:   fn,
+			args: args,
+			span: Span{Start: int(token.Start), End: int(p.tokens.end)},
+		}
+		if !fn.Deterministic {
+			return expression, nil
+		}
+
+		for _, arg := range args {
+			if _, ok := literalValue(arg); !ok {
+				return expression, nil
+			}
+		}
+
+		value, err := p.fold(expression)
+		if err != nil {
+			return nil, err
+		}
+		return coercedConstant{value: value}, nil
 	default:
-		return nil, fmt.Errorf("token is not a valid value: %v", token)
+		return nil, fmt.Errorf("token is not a valid value: `%s`", p.text(token))
+	}
+}
+
+// parsePostfix parses any `[index]` indexes, `[start:end]` slices and `.field` member accesses
+// following the value starting at the offset.
+func (p *Parser) parsePostfix(start uint32, value Expression) (Expression, error) {
+	for {
+		peeked := p.Tokenizer.Peek()
+		if peeked.IsNone() || peeked.Unwrap().IsErr() {
+			return value, nil
+		}
+
+		var postfix Expression
+		switch token := peeked.Unwrap().Unwrap(); {
+		case token.Kind == OpenBracket:
+			_ = p.Tokenizer.Next() // consume peeked bracket
+			subscript, err := p.parseSubscript(value)
+			if err != nil {
+				return nil, err
+			}
+			postfix = subscript
+		case token.Kind == SelectorPath && token.Start == p.tokens.end:
+			// a selector path immediately following the value, without any whitespace, accesses its members
+			_ = p.Tokenizer.Next() // consume peeked selector path
+			postfix = member{value: value, path: p.text(token)[1:]}
+		default:
+			return value, nil
+		}
+		postfix = withSpan(postfix, Span{Start: int(start), End: int(p.tokens.end)})
+
+		if _, ok := literalValue(value); !ok {
+			value = postfix
+			continue
+		}
+
+		result, err := p.fold(postfix)
+		if err != nil {
+			return nil, err
+		}
+		value = coercedConstant{value: result}
+	}
+}
+
+// parseSubscript parses the `index]` or `start:end]` following the opening bracket of a subscript of the value.
+func (p *Parser) parseSubscript(value Expression) (Expression, error) {
+	var bounds [2]optionext.Option[int]
+	for i := range bounds {
+		token, err := p.nextSubscriptToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Kind == Number {
+			n, err := strconv.Atoi(p.text(token))
+			if err != nil {
+				return nil, fmt.Errorf("invalid index `%s`", p.text(token))
+			}
+			bounds[i] = optionext.Some(n)
+
+			if token, err = p.nextSubscriptToken(); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case i == 0 && token.Kind == CloseBracket && bounds[0].IsSome():
+			return index{value: value, i: bounds[0].Unwrap()}, nil
+		case i == 0 && token.Kind != Colon:
+			return nil, fmt.Errorf("expected index or ':' but found `%s`", p.text(token))
+		case i == 1 && token.Kind != CloseBracket:
+			return nil, fmt.Errorf("expected ']' in slice but found `%s`", p.text(token))
+		}
+	}
+	return slice{value: value, start: bounds[0], end: bounds[1]}, nil
+}
+
+// nextSubscriptToken returns the next token within a subscript.
+func (p *Parser) nextSubscriptToken() (Token, error) {
+	next := p.Tokenizer.Next()
+	if next.IsNone() {
+		return Token{}, errors.New("unclosed subscript '['")
+	}
+
+	result := next.Unwrap()
+	if result.IsErr() {
+		return Token{}, result.Err()
+	}
+	return result.Unwrap(), nil
+}
+
+// parseArguments parses the comma separated arguments of a function call up to its closing parenthesis.
+func (p *Parser) parseArguments(name string) ([]Expression, error) {
+	inArgs := p.inArgs
+	p.depth++
+	p.inArgs = true
+	defer func() {
+		p.depth--
+		p.inArgs = inArgs
+	}()
+
+	var args []Expression
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !p.closedParen && !p.closedComma:
+			return nil, fmt.Errorf("unclosed function call %s(", name)
+		case arg == nil && p.closedParen && len(args) == 0:
+			return args, nil
+		case arg == nil:
+			return nil, fmt.Errorf("missing argument to %s", name)
+		}
+
+		args = append(args, arg)
+		if p.closedParen {
+			return args, nil
+		}
 	}
 }
 
 func (p *Parser) parseExpression() (current Expression, err error) {
+	var start uint32
 	for {
 		next := p.Tokenizer.Next()
 		if next.IsNone() {
+			p.closedParen, p.closedComma = false, false
 			return current, nil
 		}
 
 		result := next.Unwrap()
 		if result.IsErr() {
-			return nil, result.Err()
+			if !p.recovering {
+				return nil, result.Err()
+			}
+
+			if inv := p.recover(result.Err()); current == nil {
+				current = inv
+			}
+			continue
 		}
 
 		token := result.Unwrap()
+		if p.inArgs && (token.Kind == Comma || (token.Kind == CloseParen && current == nil)) {
+			if p.recovering && current == nil && token.Kind == Comma {
+				current = p.recover(errors.New("missing function argument"))
+			}
+			p.closedParen, p.closedComma = token.Kind == CloseParen, token.Kind == Comma
+			return current, nil
+		}
+
 		if current == nil {
 			// look for nextToken value
+			start = token.Start
 			current, err = p.parseValue(token)
 			if err != nil {
-				return nil, err
+				if !p.recovering {
+					return nil, err
+				}
+				current = p.recover(err)
 			}
 		} else {
 			if token.Kind == CloseParen {
+				if p.recovering && p.depth == 0 {
+					p.recover(errors.New("unmatched closing parenthesis ')'"))
+					continue
+				}
+				p.closedParen, p.closedComma = true, false
 				return current, nil
 			}
 
 			// look for nextToken operation
-			current, err = p.parseOperation(token, current)
+			operation, err := p.parseOperation(token, current)
 			if err != nil {
-				return nil, err
+				if !p.recovering {
+					return nil, err
+				}
+				// keep the operand already parsed, an invalid right hand operand having been attached where possible
+				p.recover(err)
+				continue
+			}
+			current = operation
+
+			if token.Kind != CloseBracket {
+				end := p.tokens.end
+				if (token.Kind == And || token.Kind == Or) && (p.closedParen || p.closedComma) {
+					// the right hand side ended at the parenthesis or comma closing this expression
+					end = p.tokens.prevEnd
+				}
+				current = withSpan(current, Span{Start: int(start), End: int(end)})
 			}
+
+			if (token.Kind == And || token.Kind == Or) && (p.closedParen || p.closedComma) {
+				// the right hand side consumed the parenthesis or comma closing this expression
+				return current, nil
+			}
+		}
+	}
+}
+
+// parseOperand parses the value following the operation token. When recovering, an operand that fails to parse
+// is diagnosed and returned as an invalid expression so the operation keeps its other operands.
+func (p *Parser) parseOperand(operationToken Token) (Expression, error) {
+	token, err := p.nextOperatorToken(operationToken)
+	if err == nil {
+		var value Expression
+		if value, err = p.parseValue(token); err == nil {
+			return value, nil
 		}
 	}
+
+	if !p.recovering {
+		return nil, err
+	}
+	return p.recover(err), nil
 }
 
 func (p *Parser) nextOperatorToken(operationToken Token) (token Token, err error) {
@@ -757,76 +1219,101 @@ type between struct {
 	left  Expression
 	right Expression
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (b between) Calculate(src []byte) (any, error) {
-	left, err := b.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(b)
+}
+
+func (b between) eval(env *environment) (any, error) {
+	left, err := env.eval(b.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := b.right.Calculate(src)
+	right, err := env.eval(b.right)
 	if err != nil {
 		return nil, err
 	}
 
-	value, err := b.value.Calculate(src)
+	value, err := env.eval(b.value)
 	if err != nil {
 		return nil, err
 	}
 
+	if b.opts.unknown(left, right, value) {
+		return nil, nil
+	}
+
 	// fast path, if any are nil/null there's no way to actually do the BETWEEN comparison
-	if left == nil || right == nil || value == nil {
+	if (left == nil || right == nil || value == nil) && b.opts.Mode != Strict {
 		return false, nil
 	}
 
-	leftType := reflect.TypeOf(left)
-	if !(leftType == reflect.TypeOf(right) && reflect.TypeOf(value) == leftType) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s < %s", left, right)}
+	valueType := reflect.TypeOf(value)
+	if valueType != reflect.TypeOf(left) {
+		return b.opts.mismatch(false, newTypeError(b.span, "BETWEEN", value, left))
+	} else if valueType != reflect.TypeOf(right) {
+		return b.opts.mismatch(false, newTypeError(b.span, "BETWEEN", value, right))
 	}
 
 	switch v := value.(type) {
 	case string:
-		return v > left.(string) && v < right.(string), nil
+		return b.opts.compare(v, left.(string)) > 0 && b.opts.compare(v, right.(string)) < 0, nil
 	case float64:
 		return v > left.(float64) && v < right.(float64), nil
 	case time.Time:
 		return v.After(left.(time.Time)) && v.Before(right.(time.Time)), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s < %s", left, right)}
+		return b.opts.mismatch(false, newTypeError(b.span, "BETWEEN", value, left))
 	}
 }
 
 type add struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (a add) Calculate(src []byte) (any, error) {
-	left, err := a.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(a)
+}
+
+func (a add) eval(env *environment) (any, error) {
+	left, err := env.eval(a.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := a.right.Calculate(src)
+	right, err := env.eval(a.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if a.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		if left != nil && right == nil {
+		// outside of the Strict mode a null operand is ignored
+		switch {
+		case a.opts.Mode == Strict:
+		case left != nil && right == nil:
 			switch left.(type) {
 			case string, float64:
 				return left, nil
 			}
-		} else if right != nil && left == nil {
+		case right != nil && left == nil:
 			switch right.(type) {
 			case string, float64:
 				return right, nil
 			}
 		}
 
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s + %s", left, right)}
+		return a.opts.mismatch(nil, newTypeError(a.span, "+", left, right))
 	}
 
 	switch l := left.(type) {
@@ -835,282 +1322,382 @@ func (a add) Calculate(src []byte) (any, error) {
 	case float64:
 		return l + right.(float64), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s + %s", left, right)}
+		return a.opts.mismatch(nil, newTypeError(a.span, "+", left, right))
 	}
 }
 
 type endsWith struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (e endsWith) Calculate(src []byte) (any, error) {
-	left, err := e.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(e)
+}
+
+func (e endsWith) eval(env *environment) (any, error) {
+	left, err := env.eval(e.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := e.right.Calculate(src)
+	right, err := env.eval(e.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if e.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s ENDSWITH %s", left, right)}
+		return e.opts.mismatch(false, newTypeError(e.span, "ENDSWITH", left, right))
 	}
 
 	switch l := left.(type) {
 	case string:
 		return strings.HasSuffix(l, right.(string)), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s ENDSWITH %s !", left, right)}
+		return e.opts.mismatch(false, newTypeError(e.span, "ENDSWITH", left, right))
 	}
 }
 
 type sub struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (s sub) Calculate(src []byte) (any, error) {
-	left, err := s.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(s)
+}
+
+func (s sub) eval(env *environment) (any, error) {
+	left, err := env.eval(s.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := s.right.Calculate(src)
+	right, err := env.eval(s.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s - %s", left, right)}
+		return s.opts.mismatch(nil, newTypeError(s.span, "-", left, right))
 	}
 
 	switch l := left.(type) {
 	case float64:
 		return l - right.(float64), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s - %s", left, right)}
+		return s.opts.mismatch(nil, newTypeError(s.span, "-", left, right))
 	}
 }
 
 type multi struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (m multi) Calculate(src []byte) (any, error) {
-	left, err := m.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(m)
+}
+
+func (m multi) eval(env *environment) (any, error) {
+	left, err := env.eval(m.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := m.right.Calculate(src)
+	right, err := env.eval(m.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if m.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s * %s", left, right)}
+		return m.opts.mismatch(nil, newTypeError(m.span, "*", left, right))
 	}
 
 	switch l := left.(type) {
 	case float64:
 		return l * right.(float64), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s * %s", left, right)}
+		return m.opts.mismatch(nil, newTypeError(m.span, "*", left, right))
 	}
 }
 
 type div struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (d div) Calculate(src []byte) (any, error) {
-	left, err := d.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(d)
+}
+
+func (d div) eval(env *environment) (any, error) {
+	left, err := env.eval(d.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := d.right.Calculate(src)
+	right, err := env.eval(d.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if d.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s / %s", left, right)}
+		return d.opts.mismatch(nil, newTypeError(d.span, "/", left, right))
 	}
 
 	switch l := left.(type) {
 	case float64:
 		return l / right.(float64), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s / %s", left, right)}
+		return d.opts.mismatch(nil, newTypeError(d.span, "/", left, right))
 	}
 }
 
 type eq struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (e eq) Calculate(src []byte) (any, error) {
-	left, err := e.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(e)
+}
+
+func (e eq) eval(env *environment) (any, error) {
+	left, err := env.eval(e.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := e.right.Calculate(src)
+	right, err := env.eval(e.right)
 	if err != nil {
 		return nil, err
 	}
 
-	return reflect.DeepEqual(left, right), nil
+	if e.opts.unknown(left, right) {
+		return nil, nil
+	}
+
+	return equal(left, right, e.opts.Tolerance), nil
 }
 
 type gt struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (g gt) Calculate(src []byte) (any, error) {
-	left, err := g.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(g)
+}
+
+func (g gt) eval(env *environment) (any, error) {
+	left, err := env.eval(g.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := g.right.Calculate(src)
+	right, err := env.eval(g.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if g.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s > %s", left, right)}
+		return g.opts.mismatch(false, newTypeError(g.span, ">", left, right))
 	}
 
 	switch l := left.(type) {
 	case string:
-		return l > right.(string), nil
+		return g.opts.compare(l, right.(string)) > 0, nil
 	case float64:
 		return l > right.(float64), nil
 	case time.Time:
 		return l.After(right.(time.Time)), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s > %s", left, right)}
+		return g.opts.mismatch(false, newTypeError(g.span, ">", left, right))
 	}
 }
 
 type gte struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (g gte) Calculate(src []byte) (any, error) {
-	left, err := g.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(g)
+}
+
+func (g gte) eval(env *environment) (any, error) {
+	left, err := env.eval(g.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := g.right.Calculate(src)
+	right, err := env.eval(g.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if g.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s >= %s", left, right)}
+		return g.opts.mismatch(false, newTypeError(g.span, ">=", left, right))
 	}
 
 	switch l := left.(type) {
 	case string:
-		return l >= right.(string), nil
+		return g.opts.compare(l, right.(string)) >= 0, nil
 	case float64:
 		return l >= right.(float64), nil
 	case time.Time:
 		r := right.(time.Time)
 		return l.After(r) || l.Equal(r), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s >= %s", left, right)}
+		return g.opts.mismatch(false, newTypeError(g.span, ">=", left, right))
 	}
 }
 
 type lt struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (l lt) Calculate(src []byte) (any, error) {
-	left, err := l.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(l)
+}
+
+func (l lt) eval(env *environment) (any, error) {
+	left, err := env.eval(l.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := l.right.Calculate(src)
+	right, err := env.eval(l.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if l.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s < %s", left, right)}
+		return l.opts.mismatch(false, newTypeError(l.span, "<", left, right))
 	}
 
-	switch l := left.(type) {
+	switch v := left.(type) {
 	case string:
-		return l < right.(string), nil
+		return l.opts.compare(v, right.(string)) < 0, nil
 	case float64:
-		return l < right.(float64), nil
+		return v < right.(float64), nil
 	case time.Time:
-		return l.Before(right.(time.Time)), nil
+		return v.Before(right.(time.Time)), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s < %s", left, right)}
+		return l.opts.mismatch(false, newTypeError(l.span, "<", left, right))
 	}
 }
 
 type lte struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (l lte) Calculate(src []byte) (any, error) {
-	left, err := l.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(l)
+}
+
+func (l lte) eval(env *environment) (any, error) {
+	left, err := env.eval(l.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := l.right.Calculate(src)
+	right, err := env.eval(l.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if l.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s <= %s", left, right)}
+		return l.opts.mismatch(false, newTypeError(l.span, "<=", left, right))
 	}
 
-	switch l := left.(type) {
+	switch v := left.(type) {
 	case string:
-		return l <= right.(string), nil
+		return l.opts.compare(v, right.(string)) <= 0, nil
 	case float64:
-		return l <= right.(float64), nil
+		return v <= right.(float64), nil
 	case time.Time:
 		r := right.(time.Time)
-		return l.Before(r) || l.Equal(r), nil
+		return v.Before(r) || v.Equal(r), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s <= %s", left, right)}
+		return l.opts.mismatch(false, newTypeError(l.span, "<=", left, right))
 	}
 }
 
 type or struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (o or) Calculate(src []byte) (any, error) {
-	left, err := o.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(o)
+}
+
+func (o or) eval(env *environment) (any, error) {
+	left, err := env.eval(o.left)
 	if err != nil {
 		return nil, err
 	}
 
+	if o.opts.ThreeValuedLogic {
+		return o.evalThreeValued(env, left)
+	}
+
 	switch t := left.(type) {
 	case bool:
 		if t {
@@ -1118,136 +1705,249 @@ func (o or) Calculate(src []byte) (any, error) {
 		}
 	}
 
-	right, err := o.right.Calculate(src)
+	right, err := env.eval(o.right)
 	if err != nil {
 		return nil, err
 	}
 
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s || %s", left, right)}
+		if o.opts.Mode == Lenient {
+			// unsupported operands are false
+			return right == true, nil
+		}
+		return nil, newTypeError(o.span, "||", left, right)
 	}
 
 	switch t := left.(type) {
 	case bool:
 		return t || right.(bool), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s || %s !", left, right)}
+		return o.opts.mismatch(false, newTypeError(o.span, "||", left, right))
+	}
+}
+
+// evalThreeValued evaluates the `||` with null as UNKNOWN, being `true` if either side is `true`.
+func (o or) evalThreeValued(env *environment, left any) (any, error) {
+	if left == true {
+		return true, nil
+	}
+
+	right, err := env.eval(o.right)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isLogical(left) || !isLogical(right) {
+		return o.opts.mismatch(false, newTypeError(o.span, "||", left, right))
+	}
+
+	switch {
+	case right == true:
+		return true, nil
+	case left == nil || right == nil:
+		return nil, nil
+	default:
+		return false, nil
+	}
+}
+
+// isLogical returns if the value is a boolean or null, being UNKNOWN under three-valued logic.
+func isLogical(value any) bool {
+	switch value.(type) {
+	case bool, nil:
+		return true
+	default:
+		return false
 	}
 }
 
 type and struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (a and) Calculate(src []byte) (any, error) {
-	left, err := a.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(a)
+}
+
+func (a and) eval(env *environment) (any, error) {
+	left, err := env.eval(a.left)
 	if err != nil {
 		return nil, err
 	}
 
+	if a.opts.ThreeValuedLogic {
+		return a.evalThreeValued(env, left)
+	}
+
 	switch t := left.(type) {
 	case bool:
 		if !t {
 			return false, nil
 		}
 	default:
-		return false, nil
+		if a.opts.Mode != Strict {
+			return false, nil
+		}
 	}
 
-	right, err := a.right.Calculate(src)
+	right, err := env.eval(a.right)
 	if err != nil {
 		return nil, err
 	}
 
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s && %s", left, right)}
+		return a.opts.mismatch(false, newTypeError(a.span, "&&", left, right))
 	}
 
 	switch t := left.(type) {
 	case bool:
 		return t && right.(bool), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s && %s !", left, right)}
+		return a.opts.mismatch(false, newTypeError(a.span, "&&", left, right))
+	}
+}
+
+// evalThreeValued evaluates the `&&` with null as UNKNOWN, being `false` if either side is `false`.
+func (a and) evalThreeValued(env *environment, left any) (any, error) {
+	if left == false {
+		return false, nil
+	}
+
+	right, err := env.eval(a.right)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isLogical(left) || !isLogical(right) {
+		return a.opts.mismatch(false, newTypeError(a.span, "&&", left, right))
+	}
+
+	switch {
+	case right == false:
+		return false, nil
+	case left == nil || right == nil:
+		return nil, nil
+	default:
+		return true, nil
 	}
 }
 
 type startsWith struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (s startsWith) Calculate(src []byte) (any, error) {
-	left, err := s.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(s)
+}
+
+func (s startsWith) eval(env *environment) (any, error) {
+	left, err := env.eval(s.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := s.right.Calculate(src)
+	right, err := env.eval(s.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s STARTSWITH %s", left, right)}
+		return s.opts.mismatch(false, newTypeError(s.span, "STARTSWITH", left, right))
 	}
 
 	switch l := left.(type) {
 	case string:
 		return strings.HasPrefix(l, right.(string)), nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s STARTSWITH %s !", left, right)}
+		return s.opts.mismatch(false, newTypeError(s.span, "STARTSWITH", left, right))
 	}
 }
 
 type in struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (i in) Calculate(src []byte) (any, error) {
-	left, err := i.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(i)
+}
+
+func (i in) eval(env *environment) (any, error) {
+	left, err := env.eval(i.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := i.right.Calculate(src)
+	right, err := env.eval(i.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if i.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	arr, ok := right.([]any)
 	if !ok {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s IN %s !", left, right)}
+		return i.opts.mismatch(false, newTypeError(i.span, "IN", left, right))
 	}
 
 	for _, v := range arr {
-		if left == v {
+		if err := env.iterate(); err != nil {
+			return nil, err
+		}
+		if equal(left, v, i.opts.Tolerance) {
 			return true, nil
 		}
 	}
 
+	// a null element may have been the value under three-valued logic
+	if i.opts.unknown(arr...) {
+		return nil, nil
+	}
 	return false, nil
 }
 
 type contains struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c contains) Calculate(src []byte) (any, error) {
-	left, err := c.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c contains) eval(env *environment) (any, error) {
+	left, err := env.eval(c.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := c.right.Calculate(src)
+	right, err := env.eval(c.right)
 	if err != nil {
 		return nil, err
 	}
 
-	if leftTypeOf := reflect.TypeOf(left); leftTypeOf != reflect.TypeOf(right) && leftTypeOf.Kind() != reflect.Slice {
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS %s", left, right)}
+	if c.opts.unknown(left, right) {
+		return nil, nil
+	}
+
+	if _, isArray := left.([]any); !isArray && reflect.TypeOf(left) != reflect.TypeOf(right) {
+		return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS", left, right))
 	}
 
 	switch l := left.(type) {
@@ -1255,38 +1955,57 @@ func (c contains) Calculate(src []byte) (any, error) {
 		return strings.Contains(l, right.(string)), nil
 	case []any:
 		for _, v := range l {
-			if reflect.DeepEqual(v, right) {
+			if err := env.iterate(); err != nil {
+				return nil, err
+			}
+			if equal(v, right, c.opts.Tolerance) {
 				return true, nil
 			}
 		}
+		if c.opts.unknown(l...) {
+			return nil, nil
+		}
 		return false, nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS %s !", left, right)}
+		return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS", left, right))
 	}
 }
 
 type containsAny struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c containsAny) Calculate(src []byte) (any, error) {
-	left, err := c.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c containsAny) eval(env *environment) (any, error) {
+	left, err := env.eval(c.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := c.right.Calculate(src)
+	right, err := env.eval(c.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	switch l := left.(type) {
 	case string:
 		switch r := right.(type) {
 		case string:
 			for _, c := range r {
 				for _, c2 := range l {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
 					if c == c2 {
 						return true, nil
 					}
@@ -1294,6 +2013,9 @@ func (c containsAny) Calculate(src []byte) (any, error) {
 			}
 		case []any:
 			for _, v := range r {
+				if err := env.iterate(); err != nil {
+					return nil, err
+				}
 				s, ok := v.(string)
 				if !ok {
 					continue
@@ -1304,14 +2026,17 @@ func (c containsAny) Calculate(src []byte) (any, error) {
 			}
 			return false, nil
 		default:
-			return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ANY %s", left, right)}
+			return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ANY", left, right))
 		}
 	case []any:
 		switch r := right.(type) {
 		case []any:
 			for _, rv := range r {
 				for _, lv := range l {
-					if reflect.DeepEqual(rv, lv) {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
+					if equal(rv, lv, c.opts.Tolerance) {
 						return true, nil
 					}
 				}
@@ -1319,16 +2044,20 @@ func (c containsAny) Calculate(src []byte) (any, error) {
 		case string:
 			for _, c := range r {
 				for _, v := range l {
-					if reflect.DeepEqual(string(c), v) {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
+					// a character is never a number so no tolerance applies
+					if equal(string(c), v, 0) {
 						return true, nil
 					}
 				}
 			}
 		default:
-			return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ANY %s", left, right)}
+			return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ANY", left, right))
 		}
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ANY %s !", left, right)}
+		return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ANY", left, right))
 	}
 	return false, nil
 }
@@ -1336,19 +2065,29 @@ func (c containsAny) Calculate(src []byte) (any, error) {
 type containsAll struct {
 	left  Expression
 	right Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c containsAll) Calculate(src []byte) (any, error) {
-	left, err := c.left.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c containsAll) eval(env *environment) (any, error) {
+	left, err := env.eval(c.left)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := c.right.Calculate(src)
+	right, err := env.eval(c.right)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.opts.unknown(left, right) {
+		return nil, nil
+	}
+
 	switch l := left.(type) {
 	case string:
 		switch r := right.(type) {
@@ -1356,6 +2095,9 @@ func (c containsAll) Calculate(src []byte) (any, error) {
 		OUTER1:
 			for _, c := range r {
 				for _, c2 := range l {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
 					if c == c2 {
 						continue OUTER1
 					}
@@ -1364,6 +2106,9 @@ func (c containsAll) Calculate(src []byte) (any, error) {
 			}
 		case []any:
 			for _, v := range r {
+				if err := env.iterate(); err != nil {
+					return nil, err
+				}
 				s, ok := v.(string)
 				if !ok || !strings.Contains(l, s) {
 					return false, nil
@@ -1371,7 +2116,7 @@ func (c containsAll) Calculate(src []byte) (any, error) {
 			}
 			return true, nil
 		default:
-			return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ALL %s", left, right)}
+			return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ALL", left, right))
 		}
 	case []any:
 		switch r := right.(type) {
@@ -1379,7 +2124,10 @@ func (c containsAll) Calculate(src []byte) (any, error) {
 		OUTER3:
 			for _, rv := range r {
 				for _, lv := range l {
-					if reflect.DeepEqual(rv, lv) {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
+					if equal(rv, lv, c.opts.Tolerance) {
 						continue OUTER3
 					}
 				}
@@ -1389,37 +2137,116 @@ func (c containsAll) Calculate(src []byte) (any, error) {
 		OUTER4:
 			for _, c := range r {
 				for _, v := range l {
-					if reflect.DeepEqual(string(c), v) {
+					if err := env.iterate(); err != nil {
+						return nil, err
+					}
+					// a character is never a number so no tolerance applies
+					if equal(string(c), v, 0) {
 						continue OUTER4
 					}
 				}
 				return false, nil
 			}
 		default:
-			return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ALL %s", left, right)}
+			return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ALL", left, right))
 		}
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s CONTAINS_ALL %s !", left, right)}
+		return c.opts.mismatch(false, newTypeError(c.span, "CONTAINS_ALL", left, right))
 	}
 	return true, nil
 }
 
 type not struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (n not) Calculate(src []byte) (any, error) {
-	value, err := n.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(n)
+}
+
+func (n not) eval(env *environment) (any, error) {
+	value, err := env.eval(n.value)
 	if err != nil {
 		return nil, err
 	}
 
+	if n.opts.unknown(value) {
+		return nil, nil
+	}
+
 	switch t := value.(type) {
 	case bool:
 		return !t, nil
 	default:
-		return nil, ErrUnsupportedTypeComparison{s: fmt.Sprintf("%s for !", value)}
+		return n.opts.mismatch(false, newUnaryTypeError(n.span, "!", value))
+	}
+}
+
+// exists tests whether the selector path is present, even if its value is null.
+type exists struct {
+	s string
+}
+
+func (e exists) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(e)
+}
+
+func (e exists) eval(env *environment) (any, error) {
+	_, found := env.src.Get(e.s)
+	return found, nil
+}
+
+// isNull tests whether the value is null, a missing selector path not being null.
+type isNull struct {
+	value Expression
+}
+
+func (i isNull) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(i)
+}
+
+func (i isNull) eval(env *environment) (any, error) {
+	if selector, ok := i.value.(selectorPath); ok {
+		if _, found := env.src.Get(selector.s); !found {
+			return false, nil
+		}
+	}
+
+	value, err := env.eval(i.value)
+	if err != nil {
+		return nil, err
+	}
+	return value == nil, nil
+}
+
+// isType tests whether the value is of the Kind.
+type isType struct {
+	value Expression
+	kind  Kind
+}
+
+func (i isType) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(i)
+}
+
+func (i isType) eval(env *environment) (any, error) {
+	value, err := env.eval(i.value)
+	if err != nil {
+		return nil, err
 	}
+	return ValueOf(value).Kind() == i.kind, nil
+}
+
+// typeTests are the kinds tested for by `IS <TYPE>`.
+var typeTests = map[string]Kind{
+	"STRING":   KindString,
+	"NUMBER":   KindNumber,
+	"BOOL":     KindBool,
+	"ARRAY":    KindArray,
+	"OBJECT":   KindObject,
+	"DATETIME": KindDateTime,
 }
 
 type array struct {
@@ -1427,9 +2254,13 @@ type array struct {
 }
 
 func (a array) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(a)
+}
+
+func (a array) eval(env *environment) (any, error) {
 	arr := make([]any, 0, len(a.vec))
 	for _, v := range a.vec {
-		res, err := v.Calculate(src)
+		res, err := env.eval(v)
 		if err != nil {
 			return nil, err
 		}
@@ -1448,6 +2279,10 @@ func (n num) Calculate(_ []byte) (any, error) {
 	return n.n, nil
 }
 
+func (n num) eval(_ *environment) (any, error) {
+	return n.n, nil
+}
+
 type str struct {
 	s string
 }
@@ -1456,6 +2291,10 @@ func (s str) Calculate(_ []byte) (any, error) {
 	return s.s, nil
 }
 
+func (s str) eval(_ *environment) (any, error) {
+	return s.s, nil
+}
+
 type boolean struct {
 	b bool
 }
@@ -1464,26 +2303,45 @@ func (b boolean) Calculate(_ []byte) (any, error) {
 	return b.b, nil
 }
 
+func (b boolean) eval(_ *environment) (any, error) {
+	return b.b, nil
+}
+
 type null struct{}
 
 func (bn null) Calculate(_ []byte) (any, error) {
 	return nil, nil
 }
 
+func (bn null) eval(_ *environment) (any, error) {
+	return nil, nil
+}
+
 type selectorPath struct {
 	s string
 }
 
 func (i selectorPath) Calculate(src []byte) (any, error) {
-	return gjson.GetBytes(src, i.s).Value(), nil
+	return newEnvironment(JSONSource(src)).eval(i)
+}
+
+func (i selectorPath) eval(env *environment) (any, error) {
+	value, _ := env.src.Get(i.s)
+	return value, nil
 }
 
 type coerceString struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceString) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceString) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1500,16 +2358,22 @@ func (c coerceString) Calculate(src []byte) (any, error) {
 	case time.Time:
 		return v.Format(time.RFC3339Nano), nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a string", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a string", value), Span: c.span})
 	}
 }
 
 type coerceDateTime struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceDateTime) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceDateTime) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1518,14 +2382,19 @@ func (c coerceDateTime) Calculate(src []byte) (any, error) {
 	case string:
 		t, err := dateparse.ParseAny(v)
 		if err != nil {
+			if c.opts.Mode == Strict {
+				return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unable to COERCE value: %v to a DateTime: %s", value, err), Span: c.span}
+			}
 			// don't return error at runtime but null same as not found
 			// which will fail equality checks and alike which is
 			// the desired behaviour
 			return nil, nil
 		}
 		return t, nil
+	case time.Time:
+		return v, nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a DateTime", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a DateTime", value), Span: c.span})
 	}
 }
 
@@ -1537,12 +2406,22 @@ func (c coercedConstant) Calculate(_ []byte) (any, error) {
 	return c.value, nil
 }
 
+func (c coercedConstant) eval(_ *environment) (any, error) {
+	return c.value, nil
+}
+
 type coerceUppercase struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceUppercase) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceUppercase) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1551,16 +2430,22 @@ func (c coerceUppercase) Calculate(src []byte) (any, error) {
 	case string:
 		return strings.ToUpper(v), nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a uppercase", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a uppercase", value), Span: c.span})
 	}
 }
 
 type coerceLowercase struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceLowercase) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceLowercase) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1569,16 +2454,22 @@ func (c coerceLowercase) Calculate(src []byte) (any, error) {
 	case string:
 		return strings.ToLower(v), nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a lowescase", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a lowescase", value), Span: c.span})
 	}
 }
 
 type coerceNumber struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceNumber) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceNumber) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1587,7 +2478,7 @@ func (c coerceNumber) Calculate(src []byte) (any, error) {
 	case string:
 		f, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a number", value)}
+			return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a number", value), Span: c.span})
 		}
 		return f, nil
 	case float64:
@@ -1601,16 +2492,22 @@ func (c coerceNumber) Calculate(src []byte) (any, error) {
 	case time.Time:
 		return float64(v.UnixNano()), nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a number", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a number", value), Span: c.span})
 	}
 }
 
 type coerceTitle struct {
 	value Expression
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceTitle) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceTitle) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1623,7 +2520,7 @@ func (c coerceTitle) Calculate(src []byte) (any, error) {
 		}
 		return string(unicode.ToUpper(r)) + strings.ToLower(v[1:]), nil
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a uppercase", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v to a uppercase", value), Span: c.span})
 	}
 }
 
@@ -1631,10 +2528,16 @@ type coerceSubstr struct {
 	value Expression
 	start optionext.Option[int]
 	end   optionext.Option[int]
+	span  Span
+	opts  ParseOptions
 }
 
 func (c coerceSubstr) Calculate(src []byte) (any, error) {
-	value, err := c.value.Calculate(src)
+	return newEnvironment(JSONSource(src)).eval(c)
+}
+
+func (c coerceSubstr) eval(env *environment) (any, error) {
+	value, err := env.eval(c.value)
 	if err != nil {
 		return nil, err
 	}
@@ -1645,25 +2548,33 @@ func (c coerceSubstr) Calculate(src []byte) (any, error) {
 		case c.start.IsSome() && c.end.IsSome():
 			start, end := c.start.Unwrap(), c.end.Unwrap()
 			if start < 0 || start > len(v) || end < 0 || end > len(v) {
-				return nil, nil
+				return c.outOfRange(v)
 			}
 			return v[start:end], nil
 		case c.start.IsSome() && c.end.IsNone():
 			start := c.start.Unwrap()
 			if start < 0 || start > len(v) {
-				return nil, nil
+				return c.outOfRange(v)
 			}
 			return v[start:], nil
 		case c.start.IsNone() && c.end.IsSome():
 			end := c.end.Unwrap()
 			if end < 0 || end > len(v) {
-				return nil, nil
+				return c.outOfRange(v)
 			}
 			return v[:end], nil
 		default:
-			return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v for substr, [%v:%v]", value, c.start, c.end)}
+			return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v for substr, [%v:%v]", value, c.start, c.end), Span: c.span})
 		}
 	default:
-		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v for substr", value)}
+		return c.opts.mismatch(nil, ErrUnsupportedCoerce{s: fmt.Sprintf("unsupported type COERCE for value: %v for substr", value), Span: c.span})
 	}
 }
+
+// outOfRange returns null for indexes outside of the string, or an error in the Strict mode.
+func (c coerceSubstr) outOfRange(value string) (any, error) {
+	if c.opts.Mode == Strict {
+		return nil, ErrUnsupportedCoerce{s: fmt.Sprintf("substr index out of range for value: %v", value), Span: c.span}
+	}
+	return nil, nil
+}
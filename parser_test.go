@@ -1,6 +1,7 @@
 package express
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -753,6 +754,123 @@ func TestParser(t *testing.T) {
 			src:      `{"name":"Joeybloggs"}`,
 			expected: nil,
 		},
+		{
+			name:     "EXISTS present null",
+			exp:      `EXISTS .a`,
+			src:      `{"a":null}`,
+			expected: true,
+		},
+		{
+			name:     "EXISTS missing",
+			exp:      `EXISTS .a`,
+			src:      `{}`,
+			expected: false,
+		},
+		{
+			name:     "NOT EXISTS",
+			exp:      `!EXISTS .a`,
+			src:      `{}`,
+			expected: true,
+		},
+		{
+			name:     "EXISTS non selector",
+			exp:      `EXISTS "a"`,
+			parseErr: errors.New("EXISTS requires a selector path"),
+		},
+		{
+			name:     "IS NULL null",
+			exp:      `.a IS NULL`,
+			src:      `{"a":null}`,
+			expected: true,
+		},
+		{
+			name:     "IS NULL missing",
+			exp:      `.a IS NULL`,
+			src:      `{}`,
+			expected: false,
+		},
+		{
+			name:     "IS NULL value",
+			exp:      `.a IS NULL`,
+			src:      `{"a":1}`,
+			expected: false,
+		},
+		{
+			name:     "IS NULL expression",
+			exp:      `COERCE .a _substr_[5:6] IS NULL`,
+			src:      `{"a":"abc"}`,
+			expected: true,
+		},
+		{
+			name:     "IS NOT NULL",
+			exp:      `.a IS NOT NULL && .b IS NULL`,
+			src:      `{"a":1,"b":null}`,
+			expected: true,
+		},
+		{
+			name:     "IS MISSING",
+			exp:      `.a IS MISSING`,
+			src:      `{"a":null}`,
+			expected: false,
+		},
+		{
+			name:     "IS MISSING missing",
+			exp:      `.a IS MISSING || .b`,
+			src:      `{}`,
+			expected: true,
+		},
+		{
+			name:     "IS NOT MISSING",
+			exp:      `.a IS NOT MISSING`,
+			src:      `{"a":null}`,
+			expected: true,
+		},
+		{
+			name:     "IS MISSING non selector",
+			exp:      `1 IS MISSING`,
+			parseErr: errors.New("IS MISSING requires a selector path"),
+		},
+		{
+			name:     "IS STRING",
+			exp:      `.a IS STRING`,
+			src:      `{"a":"1"}`,
+			expected: true,
+		},
+		{
+			name:     "IS NUMBER",
+			exp:      `.a IS NUMBER || .a IS BOOL`,
+			src:      `{"a":"1"}`,
+			expected: false,
+		},
+		{
+			name:     "IS ARRAY",
+			exp:      `.a IS ARRAY && .a CONTAINS 1`,
+			src:      `{"a":[1]}`,
+			expected: true,
+		},
+		{
+			name:     "IS OBJECT missing",
+			exp:      `.a IS OBJECT`,
+			src:      `{}`,
+			expected: false,
+		},
+		{
+			name:     "IS NOT OBJECT",
+			exp:      `.a IS NOT OBJECT`,
+			src:      `{"a":{"b":1}}`,
+			expected: false,
+		},
+		{
+			name:     "IS DATETIME",
+			exp:      `COERCE .a _datetime_ IS DATETIME`,
+			src:      `{"a":"2024-01-02"}`,
+			expected: true,
+		},
+		{
+			name:     "IS unknown test",
+			exp:      `.a IS EMPTY`,
+			parseErr: errors.New("invalid IS test: `EMPTY`"),
+		},
 	}
 
 	for _, tc := range tests {
@@ -795,3 +913,313 @@ func TestParserCustomCoercion(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal("*******", result)
 }
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		exp     string
+		offset  int
+		line    int
+		column  int
+		message string
+		snippet string
+		err     error
+	}{
+		{
+			name:    "invalid value",
+			exp:     `.a == 1 && .b = = 2`,
+			offset:  16,
+			line:    1,
+			column:  17,
+			message: "token is not a valid value: `=` at line 1, column 17",
+			snippet: ".a == 1 && .b = = 2\n                ^",
+		},
+		{
+			name:    "and at end",
+			exp:     `.a &&`,
+			offset:  5,
+			line:    1,
+			column:  6,
+			message: "expression after and '&&' ends unexpectedly at line 1, column 6",
+			snippet: ".a &&\n     ^",
+		},
+		{
+			name:    "or at end",
+			exp:     `.a ||`,
+			offset:  5,
+			line:    1,
+			column:  6,
+			message: "expression after or '||' ends unexpectedly at line 1, column 6",
+			snippet: ".a ||\n     ^",
+		},
+		{
+			name:    "constant call",
+			exp:     `.a > POW(10, 400)`,
+			offset:  5,
+			line:    1,
+			column:  6,
+			message: "POW results in +Inf, which is not a finite number at line 1, column 6",
+			snippet: ".a > POW(10, 400)\n     ^",
+		},
+		{
+			name:    "parenthesis at end of comparison",
+			exp:     `.a > (`,
+			offset:  6,
+			line:    1,
+			column:  7,
+			message: "expression after open parenthesis '(' ends unexpectedly at line 1, column 7",
+			snippet: ".a > (\n      ^",
+		},
+		{
+			name:    "parenthesis at end of not",
+			exp:     `! (`,
+			offset:  3,
+			line:    1,
+			column:  4,
+			message: "expression after open parenthesis '(' ends unexpectedly at line 1, column 4",
+			snippet: "! (\n   ^",
+		},
+		{
+			name:    "invalid number",
+			exp:     `.a == 123.23.23 && .b`,
+			offset:  6,
+			line:    1,
+			column:  7,
+			message: "Invalid number `123.23.23` at line 1, column 7",
+			snippet: ".a == 123.23.23 && .b\n      ^",
+			err:     ErrInvalidNumber{s: "123.23.23"},
+		},
+		{
+			name:    "unexpected end",
+			exp:     `.a == `,
+			offset:  6,
+			line:    1,
+			column:  7,
+			message: "no value found after operation: == at line 1, column 7",
+			snippet: ".a == \n      ^",
+		},
+		{
+			name:    "multiline",
+			exp:     ".a == \"é\" &&\n\t.b == fool",
+			offset:  21,
+			line:    2,
+			column:  8,
+			message: "Invalid boolean `fool` at line 2, column 8",
+			snippet: "\t.b == fool\n\t      ^",
+			err:     ErrInvalidBool{s: "fool"},
+		},
+		{
+			name:    "invalid operation",
+			exp:     `.a .b`,
+			offset:  3,
+			line:    1,
+			column:  4,
+			message: "invalid operation: `.b`, a member access must follow its value without whitespace at line 1, column 4",
+			snippet: ".a .b\n   ^",
+		},
+		{
+			name:    "coerce",
+			exp:     `COERCE .a _substr_[1:true]`,
+			offset:  21,
+			line:    1,
+			column:  22,
+			message: "Expected number after _substr_[n: but got true at line 1, column 22",
+			snippet: "COERCE .a _substr_[1:true]\n                     ^",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			_, err := Parse([]byte(tc.exp))
+
+			var parseErr ParseError
+			assert.True(errors.As(err, &parseErr))
+			assert.Equal(tc.offset, parseErr.Offset)
+			assert.Equal(tc.line, parseErr.Line)
+			assert.Equal(tc.column, parseErr.Column)
+			assert.Equal(tc.message, err.Error())
+			assert.Equal(tc.snippet, parseErr.Snippet())
+			if tc.err != nil {
+				assert.ErrorIs(err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRuntimeErrorSpan(t *testing.T) {
+	tests := []struct {
+		name     string
+		exp      string
+		src      string
+		expected ErrUnsupportedTypeComparison
+		text     string
+		message  string
+	}{
+		{
+			name:     "comparison",
+			exp:      `.a == 1 && .b > "x"`,
+			src:      `{"a":1,"b":5}`,
+			expected: ErrUnsupportedTypeComparison{Op: ">", Left: 5.0, Right: "x", LeftType: "number", RightType: "string", Span: Span{Start: 11, End: 19}},
+			text:     `.b > "x"`,
+			message:  "unsupported type comparison: `5 > \"x\"` (number > string) at [11:19]",
+		},
+		{
+			name:     "parenthesized and",
+			exp:      `(.a && .b) || .c`,
+			src:      `{"a":true,"b":"yes"}`,
+			expected: ErrUnsupportedTypeComparison{Op: "&&", Left: true, Right: "yes", LeftType: "bool", RightType: "string", Span: Span{Start: 1, End: 9}},
+			text:     `.a && .b`,
+			message:  "unsupported type comparison: `true && \"yes\"` (bool && string) at [1:9]",
+		},
+		{
+			name:     "unary",
+			exp:      `.a == 1 || !.b`,
+			src:      `{"b":[1,2]}`,
+			expected: ErrUnsupportedTypeComparison{Op: "!", Right: []any{1.0, 2.0}, RightType: "array", Span: Span{Start: 11, End: 14}},
+			text:     `!.b`,
+			message:  "unsupported type comparison: `! [1,2]` (! array) at [11:14]",
+		},
+		{
+			name:     "between",
+			exp:      `.n BETWEEN 1 "z"`,
+			src:      `{"n":5}`,
+			expected: ErrUnsupportedTypeComparison{Op: "BETWEEN", Left: 5.0, Right: "z", LeftType: "number", RightType: "string", Span: Span{Start: 0, End: 16}},
+			text:     `.n BETWEEN 1 "z"`,
+			message:  "unsupported type comparison: `5 BETWEEN \"z\"` (number BETWEEN string) at [0:16]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			_, err = ex.Calculate([]byte(tc.src))
+			var typeErr ErrUnsupportedTypeComparison
+			assert.True(errors.As(err, &typeErr))
+			assert.Equal(tc.expected, typeErr)
+			assert.Equal(tc.text, typeErr.Span.Text([]byte(tc.exp)))
+			assert.Equal(tc.message, err.Error())
+		})
+	}
+
+	assert := require.New(t)
+	exp := []byte(`.a == 1 || COERCE .b _uppercase_ == "B"`)
+	ex, err := Parse(exp)
+	assert.NoError(err)
+
+	_, err = ex.Calculate([]byte(`{"b":1}`))
+	var coerceErr ErrUnsupportedCoerce
+	assert.True(errors.As(err, &coerceErr))
+	assert.Equal("COERCE .b _uppercase_", coerceErr.Span.Text(exp))
+}
+
+func TestThreeValuedLogic(t *testing.T) {
+	tests := []struct {
+		name     string
+		exp      string
+		src      string
+		expected any
+		err      bool
+	}{
+		{name: "eq null", exp: `.a == 1`, src: `{}`, expected: nil},
+		{name: "eq null literal", exp: `.a == NULL`, src: `{"a":null}`, expected: nil},
+		{name: "not null", exp: `!(.a == 1)`, src: `{}`, expected: nil},
+		{name: "between null", exp: `.a BETWEEN 1 10`, src: `{}`, expected: nil},
+		{name: "arithmetic null", exp: `.a + 1`, src: `{}`, expected: nil},
+		{name: "startswith null", exp: `.a STARTSWITH "x"`, src: `{}`, expected: nil},
+		{name: "unknown and false", exp: `.a > 1 && .b`, src: `{"b":false}`, expected: false},
+		{name: "false and unknown", exp: `.b && .a > 1`, src: `{"b":false}`, expected: false},
+		{name: "unknown and true", exp: `.a > 1 && .b`, src: `{"b":true}`, expected: nil},
+		{name: "unknown or true", exp: `.a > 1 || .b`, src: `{"b":true}`, expected: true},
+		{name: "unknown or false", exp: `.a > 1 || .b`, src: `{"b":false}`, expected: nil},
+		{name: "true or unknown", exp: `.b || .a > 1`, src: `{"b":true}`, expected: true},
+		{name: "in found", exp: `.a IN [1, NULL]`, src: `{"a":1}`, expected: true},
+		{name: "in null element", exp: `.a IN [1, NULL]`, src: `{"a":2}`, expected: nil},
+		{name: "in not found", exp: `.a IN [1, 2]`, src: `{"a":3}`, expected: false},
+		{name: "contains null element", exp: `.a CONTAINS 2`, src: `{"a":[1,null]}`, expected: nil},
+		{name: "known", exp: `.a == 1 && .b == "x"`, src: `{"a":1,"b":"x"}`, expected: true},
+		{name: "and non boolean", exp: `.a && .b`, src: `{"b":"x"}`, err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := ParseWithOptions([]byte(tc.exp), ParseOptions{ThreeValuedLogic: true})
+			assert.NoError(err)
+
+			got, err := ex.Calculate([]byte(tc.src))
+			if tc.err {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+
+	// two-valued logic remains the default
+	assert := require.New(t)
+	ex, err := Parse([]byte(`.a == NULL`))
+	assert.NoError(err)
+	got, err := ex.Calculate([]byte(`{}`))
+	assert.NoError(err)
+	assert.Equal(true, got)
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     Mode
+		exp      string
+		src      string
+		expected any
+		err      bool
+	}{
+		{name: "default and non boolean", mode: Default, exp: `.a && true`, src: `{"a":"x"}`, expected: false},
+		{name: "default comparison mismatch", mode: Default, exp: `.a > 1`, src: `{"a":"x"}`, err: true},
+		{name: "default contains null", mode: Default, exp: `.missing CONTAINS "x"`, src: `{}`, err: true},
+		{name: "default unparsable datetime", mode: Default, exp: `COERCE .d _datetime_`, src: `{"d":"nope"}`, expected: nil},
+		{name: "strict and non boolean", mode: Strict, exp: `.a && true`, src: `{"a":"x"}`, err: true},
+		{name: "strict unparsable datetime", mode: Strict, exp: `COERCE .d _datetime_`, src: `{"d":"nope"}`, err: true},
+		{name: "strict substr out of range", mode: Strict, exp: `COERCE .s _substr_[0:10]`, src: `{"s":"abc"}`, err: true},
+		{name: "strict substr in range", mode: Strict, exp: `COERCE .s _substr_[0:2]`, src: `{"s":"abc"}`, expected: "ab"},
+		{name: "strict between null", mode: Strict, exp: `.a BETWEEN 1 10`, src: `{}`, err: true},
+		{name: "strict add null", mode: Strict, exp: `.a + 1`, src: `{}`, err: true},
+		{name: "strict add mismatch", mode: Strict, exp: `.a + .b`, src: `{"a":1,"b":"x"}`, err: true},
+		{name: "strict add mismatch reversed", mode: Strict, exp: `.a + .b`, src: `{"a":"x","b":1}`, err: true},
+		{name: "lenient comparison mismatch", mode: Lenient, exp: `.a > 1`, src: `{"a":"x"}`, expected: false},
+		{name: "lenient arithmetic mismatch", mode: Lenient, exp: `.a + 1`, src: `{"a":true}`, expected: nil},
+		{name: "lenient not non boolean", mode: Lenient, exp: `!.a`, src: `{"a":"x"}`, expected: false},
+		{name: "lenient or non boolean", mode: Lenient, exp: `.a || true`, src: `{"a":"x"}`, expected: true},
+		{name: "lenient and non boolean", mode: Lenient, exp: `true && .a`, src: `{"a":"x"}`, expected: false},
+		{name: "lenient in non array", mode: Lenient, exp: `.a IN "x"`, src: `{"a":"x"}`, expected: false},
+		{name: "lenient contains null", mode: Lenient, exp: `.missing CONTAINS "x"`, src: `{}`, expected: false},
+		{name: "lenient coerce mismatch", mode: Lenient, exp: `COERCE .a _uppercase_`, src: `{"a":1}`, expected: nil},
+		{name: "lenient nested", mode: Lenient, exp: `.a > 1 || .b == 2`, src: `{"a":"x","b":2}`, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := ParseWithOptions([]byte(tc.exp), ParseOptions{Mode: tc.mode})
+			assert.NoError(err)
+
+			got, err := ex.Calculate([]byte(tc.src))
+			if tc.err {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+
+	// constants are coerced with the mode when parsed
+	assert := require.New(t)
+	_, err := ParseWithOptions([]byte(`COERCE "nope" _datetime_`), ParseOptions{Mode: Strict})
+	var coerceErr ErrUnsupportedCoerce
+	assert.True(errors.As(err, &coerceErr))
+}
@@ -0,0 +1,80 @@
+package express
+
+import (
+	"errors"
+
+	"github.com/pchchv/extender/optionext"
+)
+
+var _ Expression = (*invalid)(nil)
+
+// ParseRecover parses the expression the same as Parse but, rather than stopping at the first syntax error,
+// resumes parsing at the next `)`, `]`, `,`, `&&` or `||` to report every error in the expression at once.
+//
+// The partially parsed Expression is returned along with the errors, the parts of it
+// that could not be parsed returning their ParseError when calculated.
+func ParseRecover(expression []byte) (Expression, []ParseError) {
+	p := newParser(expression)
+	p.recovering = true
+
+	// errors are recorded as diagnostics while recovering
+	result, _ := p.parseExpression()
+	if result == nil && len(p.diagnostics) == 0 {
+		p.diagnostics = append(p.diagnostics, newParseError(expression, len(expression), errors.New("no expression results found")))
+	}
+	return result, p.diagnostics
+}
+
+// recover records the error and skips to the next token parsing can resume from,
+// returning an invalid expression to stand in for the part that could not be parsed.
+func (p *Parser) recover(err error) Expression {
+	parseErr := p.diagnose(err)
+	p.synchronize()
+	return invalid{err: parseErr}
+}
+
+// diagnose records the error at the current position, skipping any input that could not be lexed.
+func (p *Parser) diagnose(err error) ParseError {
+	parseErr := newParseError(p.Exp, p.tokens.offset(), err)
+	p.diagnostics = append(p.diagnostics, parseErr)
+	if p.tokens.lexErr.IsSome() {
+		p.tokens.tokenizer.skipInvalid()
+		p.tokens.lexErr = optionext.None[uint32]()
+	}
+	return parseErr
+}
+
+// synchronize skips tokens up to, but not including, the next `)`, `]`, `,`, `&&` or `||`.
+func (p *Parser) synchronize() {
+	for {
+		peeked := p.Tokenizer.Peek()
+		if peeked.IsNone() {
+			return
+		}
+
+		if result := peeked.Unwrap(); result.IsErr() {
+			_ = p.Tokenizer.Next()
+			p.diagnose(result.Err())
+			continue
+		}
+
+		switch peeked.Unwrap().Unwrap().Kind {
+		case CloseParen, CloseBracket, Comma, And, Or:
+			return
+		}
+		_ = p.Tokenizer.Next()
+	}
+}
+
+// invalid stands in for a part of an expression that could not be parsed by ParseRecover.
+type invalid struct {
+	err ParseError
+}
+
+func (i invalid) Calculate(_ []byte) (any, error) {
+	return nil, i.err
+}
+
+func (i invalid) eval(_ *environment) (any, error) {
+	return nil, i.err
+}
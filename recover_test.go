@@ -0,0 +1,141 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecover(t *testing.T) {
+	tests := []struct {
+		name        string
+		exp         string
+		messages    []string
+		offsets     []int
+		partial     string
+		src         string
+		expected    any
+		calculateOk bool
+	}{
+		{
+			name:        "valid",
+			exp:         `.a == 1 && .b IN ["x", "y"]`,
+			partial:     `(.a == 1) && (.b IN ["x", "y"])`,
+			src:         `{"a":1,"b":"y"}`,
+			expected:    true,
+			calculateOk: true,
+		},
+		{
+			name: "several errors",
+			exp:  `.a = = 1 && .b > fool || (.c == 123.23.23) && .d`,
+			messages: []string{
+				"token is not a valid value: `=` at line 1, column 6",
+				"Invalid boolean `fool` at line 1, column 18",
+				"Invalid number `123.23.23` at line 1, column 33",
+			},
+			offsets: []int{5, 17, 32},
+			partial: `(.a == <invalid>) && ((.b > <invalid>) || ((.c == <invalid>) && .d))`,
+		},
+		{
+			name: "array elements",
+			exp:  `.a IN [1, =, "x", 'y]`,
+			messages: []string{
+				"token is not a valid value: `=` at line 1, column 11",
+				"Unterminated string `'y]` at line 1, column 19",
+			},
+			offsets: []int{10, 18},
+			partial: `.a IN [1, <invalid>, "x"]`,
+		},
+		{
+			name: "unmatched parenthesis and missing operand",
+			exp:  `.a == 1) || .b &&`,
+			messages: []string{
+				"unmatched closing parenthesis ')' at line 1, column 8",
+				"expression after and '&&' ends unexpectedly at line 1, column 18",
+			},
+			offsets: []int{7, 17},
+			partial: `(.a == 1) || (.b && <invalid>)`,
+		},
+		{
+			name: "invalid operation keeps operand",
+			exp:  `.a .b && .c BETWEEN fool 10`,
+			messages: []string{
+				"invalid operation: `.b`, a member access must follow its value without whitespace at line 1, column 4",
+				"Invalid boolean `fool` at line 1, column 21",
+			},
+			offsets: []int{3, 20},
+			partial: `.a && (.c BETWEEN <invalid> <invalid>)`,
+		},
+		{
+			name: "parenthesis at end of array",
+			exp:  `[ (`,
+			messages: []string{
+				"expression after open parenthesis '(' ends unexpectedly at line 1, column 4",
+				"unclosed Array '[' at line 1, column 4",
+			},
+			offsets: []int{3, 3},
+			partial: `[<invalid>]`,
+		},
+		{
+			name: "parenthesis at end of operand",
+			exp:  `"x" IS NULL CONTAINS_ANY (`,
+			messages: []string{
+				"expression after open parenthesis '(' ends unexpectedly at line 1, column 27",
+			},
+			offsets: []int{26},
+			partial: `("x" IS NULL) CONTAINS_ANY <invalid>`,
+		},
+		{
+			name: "empty",
+			exp:  ` `,
+			messages: []string{
+				"no expression results found at line 1, column 2",
+			},
+			offsets: []int{1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, diagnostics := ParseRecover([]byte(tc.exp))
+
+			var messages []string
+			var offsets []int
+			for _, d := range diagnostics {
+				messages = append(messages, d.Error())
+				offsets = append(offsets, d.Offset)
+			}
+			assert.Equal(tc.messages, messages)
+			assert.Equal(tc.offsets, offsets)
+
+			if tc.partial == "" {
+				assert.Nil(ex)
+				return
+			}
+			assert.Equal(tc.partial, format(ex))
+
+			if tc.calculateOk {
+				got, err := ex.Calculate([]byte(tc.src))
+				assert.NoError(err)
+				assert.Equal(tc.expected, got)
+				return
+			}
+
+			// invalid parts of a partial expression report errors rather than panic
+			assert.NotPanics(func() {
+				_, _ = ex.Calculate([]byte(tc.src))
+				_, _ = Explain(ex, []byte(tc.src))
+			})
+		})
+	}
+}
+
+func TestParseRecoverInvalidCalculate(t *testing.T) {
+	assert := require.New(t)
+	ex, diagnostics := ParseRecover([]byte(`.a == 1 || = 2`))
+	assert.Len(diagnostics, 1)
+
+	_, err := ex.Calculate([]byte(`{"a":2}`))
+	assert.Equal(diagnostics[0], err)
+}
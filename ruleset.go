@@ -0,0 +1,199 @@
+package express
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	_ Expression = (*memo)(nil)
+	_ Expression = (*ref)(nil)
+)
+
+// RuleSet evaluates many named expressions against the same payload.
+//
+// Subexpressions shared between rules are only evaluated once per payload and every
+// selector used by the rules is extracted from the payload up front.
+// A RuleSet is immutable and safe for concurrent use.
+type RuleSet struct {
+	ids   []string
+	rules []Expression
+	paths []string
+	slots int
+}
+
+// Match is a rule that matched the payload supplied to RuleSet.Evaluate.
+type Match struct {
+	ID    string
+	Value any
+}
+
+// NewRuleSet compiles the supplied rules, keyed by their ID, into a RuleSet.
+func NewRuleSet(rules map[string]Expression) *RuleSet {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	c := &ruleCompiler{keys: make(map[string]int)}
+	roots := make([]int, 0, len(ids))
+	for _, id := range ids {
+		roots = append(roots, c.intern(rules[id]))
+	}
+
+	r := &RuleSet{
+		ids:   ids,
+		rules: make([]Expression, 0, len(ids)),
+	}
+	c.built = make([]Expression, len(c.exprs))
+	for _, root := range roots {
+		r.rules = append(r.rules, c.build(root))
+	}
+
+	for _, e := range c.exprs {
+		if sel, ok := e.(selectorPath); ok {
+			r.paths = append(r.paths, sel.s)
+		}
+	}
+	r.slots = c.slots
+	return r
+}
+
+// Evaluate applies every rule to the supplied JSON and returns the rules that
+// matched, in order of their ID, with the values they evaluated to.
+//
+// A rule matches when it evaluates to any value other than `false` or null.
+// A rule failing to evaluate does not stop the others, the matches being returned along with
+// an error joining an ErrRule for each rule that failed.
+func (r *RuleSet) Evaluate(src []byte) ([]Match, error) {
+	doc := NewDocument(src)
+	for i, result := range gjson.GetManyBytes(src, r.paths...) {
+		doc.cache[r.paths[i]] = documentValue{value: result.Value(), exists: result.Exists()}
+	}
+
+	env := newEnvironment(doc)
+	env.memo = make([]memoResult, r.slots)
+
+	var matches []Match
+	var errs []error
+	for i, rule := range r.rules {
+		value, err := env.eval(rule)
+		if err != nil {
+			errs = append(errs, ErrRule{ID: r.ids[i], Err: err})
+			continue
+		}
+
+		if b, ok := value.(bool); (ok && !b) || value == nil {
+			continue
+		}
+		matches = append(matches, Match{ID: r.ids[i], Value: value})
+	}
+	return matches, errors.Join(errs...)
+}
+
+// ruleCompiler interns structurally identical subexpressions of many expressions.
+type ruleCompiler struct {
+	keys     map[string]int
+	exprs    []Expression
+	children [][]int
+	counts   []int
+	built    []Expression
+	slots    int
+}
+
+// intern returns the id of the expression, identical expressions sharing the same id.
+func (c *ruleCompiler) intern(e Expression) int {
+	var ids []int
+	node := mapChildren(e, func(child Expression) Expression {
+		id := c.intern(child)
+		ids = append(ids, id)
+		return ref{id: id}
+	})
+
+	// identical expressions at different locations are the same
+	key := fmt.Sprintf("%#v", withSpan(node, Span{}))
+	id, found := c.keys[key]
+	if !found {
+		id = len(c.exprs)
+		c.keys[key] = id
+		c.exprs = append(c.exprs, e)
+		c.children = append(c.children, ids)
+		c.counts = append(c.counts, 0)
+	}
+	c.counts[id]++
+	return id
+}
+
+// build rebuilds the interned expression, wrapping those used more than once in a memo.
+func (c *ruleCompiler) build(id int) Expression {
+	if c.built[id] != nil {
+		return c.built[id]
+	}
+
+	var i int
+	e := mapChildren(c.exprs[id], func(_ Expression) Expression {
+		child := c.build(c.children[id][i])
+		i++
+		return child
+	})
+
+	if c.counts[id] > 1 && !isCheap(e) {
+		e = &memo{slot: c.slots, value: e}
+		c.slots++
+	}
+	c.built[id] = e
+	return e
+}
+
+// isCheap returns true for expressions that are cheaper to evaluate again than to cache,
+// selectors are already cached by the Document.
+func isCheap(e Expression) bool {
+	switch e.(type) {
+	case selectorPath, num, str, boolean, null, coercedConstant:
+		return true
+	default:
+		return false
+	}
+}
+
+// ref stands in for an interned subexpression while computing structural keys.
+type ref struct {
+	id int
+}
+
+func (r ref) Calculate(_ []byte) (any, error) {
+	return nil, errors.New("unresolved subexpression reference")
+}
+
+type memoResult struct {
+	done  bool
+	value any
+	err   error
+}
+
+// memo caches the result of a subexpression shared between rules for a single evaluation.
+type memo struct {
+	slot  int
+	value Expression
+}
+
+func (m *memo) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(m)
+}
+
+func (m *memo) eval(env *environment) (any, error) {
+	if m.slot >= len(env.memo) {
+		return env.eval(m.value)
+	}
+
+	result := &env.memo[m.slot]
+	if !result.done {
+		result.value, result.err = env.eval(m.value)
+		result.done = true
+	}
+	return result.value, result.err
+}
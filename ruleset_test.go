@@ -0,0 +1,78 @@
+package express
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Counter struct {
+	calls      *atomic.Int64
+	expression Expression
+}
+
+func (c *Counter) Calculate(json []byte) (interface{}, error) {
+	c.calls.Add(1)
+	return c.expression.Calculate(json)
+}
+
+func TestRuleSet(t *testing.T) {
+	assert := require.New(t)
+	calls := new(atomic.Int64)
+	guard := Coercions.Lock()
+	guard.T["_count_"] = func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		return false, &Counter{calls: calls, expression: expression}, nil
+	}
+	guard.Unlock()
+
+	rules := map[string]string{
+		"employees":  `.properties.employees > 20 && .name == "Company"`,
+		"small":      `.properties.employees > 20 && .properties.employees < 30`,
+		"name":       `.name + "!"`,
+		"counted":    `COERCE .name _count_ == "Company"`,
+		"counted2":   `COERCE .name _count_ != "Company"`,
+		"null":       `.missing`,
+		"not_public": `!.public`,
+	}
+
+	parsed := make(map[string]Expression, len(rules))
+	for id, rule := range rules {
+		ex, err := Parse([]byte(rule))
+		assert.NoError(err)
+		parsed[id] = ex
+	}
+
+	rs := NewRuleSet(parsed)
+	// `.properties.employees > 20`, `COERCE .name _count_` and its comparison are shared
+	assert.Equal(3, rs.slots)
+	assert.ElementsMatch([]string{"properties.employees", "name", "missing", "public"}, rs.paths)
+
+	expected := []Match{
+		{ID: "counted", Value: true},
+		{ID: "employees", Value: true},
+		{ID: "name", Value: "Company!"},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches, err := rs.Evaluate([]byte(`{"name":"Company","public":true,"properties":{"employees":50}}`))
+			assert.NoError(err)
+			assert.Equal(expected, matches)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(int64(10), calls.Load())
+
+	// a failing rule is reported without stopping the others
+	matches, err := rs.Evaluate([]byte(`{"name":"Company","public":"yes","properties":{"employees":50}}`))
+	assert.Equal(expected, matches)
+	var ruleErr ErrRule
+	assert.True(errors.As(err, &ruleErr))
+	assert.Equal("not_public", ruleErr.ID)
+}
@@ -0,0 +1,154 @@
+package express
+
+import "slices"
+
+var _ Expression = (*setOperation)(nil)
+
+// setOperators are the names of the operators comparing arrays as sets.
+var setOperators = map[TokenKind]string{
+	Intersects: "INTERSECTS",
+	SubsetOf:   "SUBSET_OF",
+	SupersetOf: "SUPERSET_OF",
+	Disjoint:   "DISJOINT",
+	SetEquals:  "SET_EQUALS",
+}
+
+// setOperation compares two arrays as sets of their elements, ignoring their order and any repeats.
+// Unlike CONTAINS_ANY and CONTAINS_ALL strings are never treated as sets of their characters.
+type setOperation struct {
+	op    TokenKind
+	left  Expression
+	right Expression
+	span  Span
+	opts  ParseOptions
+}
+
+func (s setOperation) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(s)
+}
+
+func (s setOperation) eval(env *environment) (any, error) {
+	left, err := env.eval(s.left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := env.eval(s.right)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.opts.unknown(left, right) {
+		return nil, nil
+	}
+
+	l, lok := left.([]any)
+	r, rok := right.([]any)
+	if !lok || !rok {
+		return s.opts.mismatch(false, newTypeError(s.span, setOperators[s.op], left, right))
+	}
+
+	switch s.op {
+	case Intersects:
+		return intersects(env, l, r, s.opts.Tolerance)
+	case Disjoint:
+		found, err := intersects(env, l, r, s.opts.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		return !found, nil
+	case SubsetOf:
+		return subsetOf(env, l, r, s.opts.Tolerance)
+	case SupersetOf:
+		return subsetOf(env, r, l, s.opts.Tolerance)
+	default:
+		subset, err := subsetOf(env, l, r, s.opts.Tolerance)
+		if err != nil || !subset {
+			return subset, err
+		}
+		return subsetOf(env, r, l, s.opts.Tolerance)
+	}
+}
+
+// intersects reports whether the arrays have any element in common.
+func intersects(env *environment, a, b []any, tolerance float64) (bool, error) {
+	elements, err := newSet(env, b, tolerance)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range a {
+		if err := env.iterate(); err != nil {
+			return false, err
+		}
+		if elements.has(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// subsetOf reports whether every element of the first array is also in the second.
+func subsetOf(env *environment, a, b []any, tolerance float64) (bool, error) {
+	elements, err := newSet(env, b, tolerance)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range a {
+		if err := env.iterate(); err != nil {
+			return false, err
+		}
+		if !elements.has(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// set holds the elements of an array for lookup, hashing scalars and comparing any arrays, objects
+// or, when there is a tolerance, numbers the same as CONTAINS.
+type set struct {
+	scalars   map[any]struct{}
+	others    []any
+	tolerance float64
+}
+
+func newSet(env *environment, arr []any, tolerance float64) (set, error) {
+	s := set{scalars: make(map[any]struct{}, len(arr)), tolerance: tolerance}
+	for _, v := range arr {
+		if err := env.iterate(); err != nil {
+			return set{}, err
+		}
+
+		s.add(v)
+	}
+	return s, nil
+}
+
+func (s *set) add(value any) {
+	if key, ok := s.key(value); ok {
+		s.scalars[key] = struct{}{}
+	} else {
+		s.others = append(s.others, value)
+	}
+}
+
+func (s set) has(value any) bool {
+	if key, ok := s.key(value); ok {
+		_, found := s.scalars[key]
+		return found
+	}
+
+	return slices.ContainsFunc(s.others, func(v any) bool {
+		return equal(v, value, s.tolerance)
+	})
+}
+
+// key returns the map key of the value, reporting false when it must be compared with the others.
+func (s set) key(value any) (any, bool) {
+	if _, isNumber := number(value); isNumber && s.tolerance != 0 {
+		return nil, false
+	}
+	return equalityKey(value)
+}
@@ -0,0 +1,152 @@
+package express
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOperators(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "intersects",
+			exp:      `.a INTERSECTS ["x", "y"]`,
+			src:      `{"a":["y","z"]}`,
+			expected: true,
+		},
+		{
+			name:     "intersects none",
+			exp:      `.a INTERSECTS ["x", "y"]`,
+			src:      `{"a":["z"]}`,
+			expected: false,
+		},
+		{
+			name:     "intersects empty",
+			exp:      `.a INTERSECTS []`,
+			src:      `{"a":["z"]}`,
+			expected: false,
+		},
+		{
+			name:     "intersects objects",
+			exp:      `.a INTERSECTS .b`,
+			src:      `{"a":[{"x":1},[2]],"b":[[2]]}`,
+			expected: true,
+		},
+		{
+			name:     "intersects types",
+			exp:      `.a INTERSECTS [1, true]`,
+			src:      `{"a":["1","true"]}`,
+			expected: false,
+		},
+		{
+			name:     "disjoint",
+			exp:      `.a DISJOINT [1, 2]`,
+			src:      `{"a":[3,4]}`,
+			expected: true,
+		},
+		{
+			name:     "not disjoint",
+			exp:      `.a DISJOINT [1, 2]`,
+			src:      `{"a":[2,3]}`,
+			expected: false,
+		},
+		{
+			name:     "subset",
+			exp:      `.a SUBSET_OF ["x", "y", "z"]`,
+			src:      `{"a":["z","x","x"]}`,
+			expected: true,
+		},
+		{
+			name:     "not subset",
+			exp:      `.a SUBSET_OF ["x", "y"]`,
+			src:      `{"a":["x","w"]}`,
+			expected: false,
+		},
+		{
+			name:     "empty subset",
+			exp:      `[] SUBSET_OF .a`,
+			src:      `{"a":[]}`,
+			expected: true,
+		},
+		{
+			name:     "superset",
+			exp:      `.a SUPERSET_OF ["x", "y"]`,
+			src:      `{"a":["y","z","x"]}`,
+			expected: true,
+		},
+		{
+			name:     "not superset",
+			exp:      `.a SUPERSET_OF ["x", "y"]`,
+			src:      `{"a":["y"]}`,
+			expected: false,
+		},
+		{
+			name:     "set equals",
+			exp:      `.a SET_EQUALS [1, 2, 3]`,
+			src:      `{"a":[3,1,2,1]}`,
+			expected: true,
+		},
+		{
+			name:     "set not equals",
+			exp:      `.a SET_EQUALS [1, 2, 3]`,
+			src:      `{"a":[3,1]}`,
+			expected: false,
+		},
+		{
+			name:     "set equals nulls",
+			exp:      `.a SET_EQUALS [NULL, 1]`,
+			src:      `{"a":[1,null]}`,
+			expected: true,
+		},
+		{
+			name:     "gjson path",
+			exp:      `.items.#.sku SUBSET_OF .allowed`,
+			src:      `{"items":[{"sku":"a"},{"sku":"b"}],"allowed":["a","b","c"]}`,
+			expected: true,
+		},
+		{
+			name: "strings",
+			exp:  `.a INTERSECTS "abc"`,
+			src:  `{"a":"cde"}`,
+			err:  ErrUnsupportedTypeComparison{Op: "INTERSECTS", Left: "cde", Right: "abc", LeftType: "string", RightType: "string", Span: Span{Start: 0, End: 19}},
+		},
+		{
+			name:     "strings lenient",
+			exp:      `.a SUBSET_OF "abc"`,
+			opts:     ParseOptions{Mode: Lenient},
+			src:      `{"a":"ab"}`,
+			expected: false,
+		},
+		{
+			name: "missing",
+			exp:  `.a DISJOINT [1]`,
+			src:  `{}`,
+			err:  ErrUnsupportedTypeComparison{Op: "DISJOINT", Left: nil, Right: []any{1.0}, LeftType: "null", RightType: "array", Span: Span{Start: 0, End: 15}},
+		},
+	})
+}
+
+func TestSetOperatorsFormat(t *testing.T) {
+	assert := require.New(t)
+	for _, exp := range []string{
+		`.a INTERSECTS ["x", "y"]`,
+		`.a DISJOINT [1, 2]`,
+		`.a SUBSET_OF .b`,
+		`.a SUPERSET_OF ["x"]`,
+		`.a SET_EQUALS [NULL, 1]`,
+	} {
+		ex, err := Parse([]byte(exp))
+		assert.NoError(err)
+		assert.Equal(exp, format(ex))
+	}
+}
+
+func TestSetOperatorsThreeValued(t *testing.T) {
+	assert := require.New(t)
+	ex, err := ParseWithOptions([]byte(`.a INTERSECTS [1]`), ParseOptions{ThreeValuedLogic: true})
+	assert.NoError(err)
+
+	got, err := ex.Calculate([]byte(`{}`))
+	assert.NoError(err)
+	assert.Nil(got)
+}
@@ -0,0 +1,392 @@
+package express
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	_ Source = (JSONSource)(nil)
+	_ Source = (MapSource)(nil)
+	_ Source = (*structSource)(nil)
+
+	timeType          = reflect.TypeOf(time.Time{})
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	// structFields caches the JSON field names of each struct type.
+	structFields sync.Map
+)
+
+// Source is the data an Expression is applied to.
+type Source interface {
+	// Get returns the value found at the selector path and whether it exists.
+	//
+	// Values must be one of the types produced by decoding JSON, nil, bool, float64,
+	// string, []any and map[string]any, or a time.Time.
+	Get(path string) (value any, exists bool)
+}
+
+// JSONSource is a Source of raw JSON whose selector paths support the full gjson syntax.
+type JSONSource []byte
+
+// Get returns the value found at the gjson path.
+func (s JSONSource) Get(path string) (any, bool) {
+	result := gjson.GetBytes(s, path)
+	return result.Value(), result.Exists()
+}
+
+// MapSource is a Source of decoded JSON or any other Go values held in a map.
+//
+// Selector paths are object keys and array indexes separated by a `.`,
+// the remaining gjson syntax such as wildcards and queries is not supported.
+type MapSource map[string]any
+
+// Get returns the value found at the selector path.
+func (s MapSource) Get(path string) (any, bool) {
+	return lookup(reflect.ValueOf(map[string]any(s)), path)
+}
+
+// StructSource returns a Source for a struct, or pointer to one, whose fields are
+// resolved by their `json` tag names the same as they would be once encoded as JSON.
+//
+// Selector paths are object keys and array indexes separated by a `.`,
+// the remaining gjson syntax such as wildcards and queries is not supported.
+func StructSource(v any) Source {
+	return &structSource{v: v}
+}
+
+type structSource struct {
+	v any
+}
+
+// Get returns the value found at the selector path.
+func (s *structSource) Get(path string) (any, bool) {
+	return lookup(reflect.ValueOf(s.v), path)
+}
+
+// MarshalJSON encodes the underlying struct for use by custom expressions.
+func (s *structSource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.v)
+}
+
+// lookup walks the selector path through maps, slices and structs returning the value found as a JSON type.
+func lookup(v reflect.Value, path string) (any, bool) {
+	segments, ok := selectorSegments(path)
+	if !ok {
+		return nil, false
+	}
+
+	var quote bool
+	for _, segment := range segments {
+		if v = indirect(v); !v.IsValid() {
+			return nil, false
+		}
+
+		if value, ok := marshaled(v); ok {
+			v = reflect.ValueOf(value)
+		}
+
+		quote = false
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+
+			if v = v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key())); !v.IsValid() {
+				return nil, false
+			}
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= v.Len() {
+				return nil, false
+			}
+			v = v.Index(i)
+		case reflect.Struct:
+			index, found := jsonFields(v.Type())[segment]
+			if !found {
+				return nil, false
+			}
+
+			field, err := v.FieldByIndexErr(index)
+			if err != nil || omitted(v.Type(), index, field) {
+				return nil, false
+			}
+			v, quote = field, tagOption(v.Type(), index, "string")
+		default:
+			return nil, false
+		}
+	}
+
+	if quote {
+		return quoted(normalize(v)), true
+	}
+	return normalize(v), true
+}
+
+// indirect dereferences pointers and interfaces returning an invalid value if any are nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// normalize converts a Go value into the types produced by decoding JSON, the same as encoding/json would encode it
+// except for time.Time values which are kept. Values referencing themselves, which encoding/json rejects, result in null.
+func normalize(v reflect.Value) any {
+	return normalizeWithin(v, make(map[visit]struct{}))
+}
+
+// visit identifies a pointer, map or slice being normalized, a cycle revisiting one before it is complete.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// normalizeWithin normalizes the value given the pointers, maps and slices being normalized that contain it.
+func normalizeWithin(v reflect.Value, seen map[visit]struct{}) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+
+		if v.Kind() == reflect.Pointer {
+			key := visit{ptr: v.Pointer(), typ: v.Type()}
+			if _, found := seen[key]; found {
+				return nil
+			}
+			seen[key] = struct{}{}
+			defer delete(seen, key)
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == timeType {
+		return v.Interface()
+	}
+
+	if value, ok := marshaled(v); ok {
+		return value
+	}
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+
+		key := visit{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}
+		if _, found := seen[key]; found {
+			return nil
+		}
+		seen[key] = struct{}{}
+		defer delete(seen, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			// same as encoding/json
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+
+		arr := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			arr = append(arr, normalizeWithin(v.Index(i), seen))
+		}
+		return arr
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = normalizeWithin(iter.Value(), seen)
+		}
+		return m
+	case reflect.Struct:
+		m := make(map[string]any)
+		for name, index := range jsonFields(v.Type()) {
+			field, err := v.FieldByIndexErr(index)
+			if err != nil || omitted(v.Type(), index, field) {
+				continue
+			}
+
+			if tagOption(v.Type(), index, "string") {
+				m[name] = quoted(normalizeWithin(field, seen))
+			} else {
+				m[name] = normalizeWithin(field, seen)
+			}
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// marshaled returns the decoded JSON encoding of a json.Marshaler, or the text of an encoding.TextMarshaler,
+// and whether the value is one. A value that fails to marshal results in null.
+func marshaled(v reflect.Value) (any, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	// the same precedence as encoding/json, preferring pointer receivers when addressable
+	var m any
+	for _, iface := range []reflect.Type{marshalerType, textMarshalerType} {
+		if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(iface) {
+			m = v.Addr().Interface()
+		} else if v.Type().Implements(iface) {
+			m = v.Interface()
+		} else {
+			continue
+		}
+		break
+	}
+
+	switch m := m.(type) {
+	case json.Marshaler:
+		b, err := m.MarshalJSON()
+		if err != nil {
+			return nil, true
+		}
+
+		var value any
+		if err := json.Unmarshal(b, &value); err != nil {
+			return nil, true
+		}
+		return value, true
+	case encoding.TextMarshaler:
+		b, err := m.MarshalText()
+		if err != nil {
+			return nil, true
+		}
+		return string(b), true
+	default:
+		return nil, false
+	}
+}
+
+// quoted returns a normalized field tagged `,string` encoded as a string, the same as encoding/json.
+func quoted(value any) any {
+	switch value.(type) {
+	case bool, float64, string:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return value
+	}
+}
+
+// jsonFields returns the index of each exported field of a struct by its JSON name,
+// including the promoted fields of embedded structs.
+func jsonFields(t reflect.Type) map[string][]int {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields := make(map[string][]int)
+	// embedding types being collected, a struct embedding itself through a pointer adding no further fields
+	embedding := make(map[reflect.Type]bool)
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		embedding[t] = true
+		defer delete(embedding, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			name, _, _ := strings.Cut(tag, ",")
+			fieldIndex := append(append([]int{}, index...), i)
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				if !embedding[ft] {
+					collect(ft, fieldIndex)
+				}
+				continue
+			}
+
+			if !f.IsExported() {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			// fields closer to the root take precedence over promoted ones
+			if existing, found := fields[name]; !found || len(existing) > len(fieldIndex) {
+				fields[name] = fieldIndex
+			}
+		}
+	}
+
+	collect(t, nil)
+	structFields.Store(t, fields)
+	return fields
+}
+
+// omitted returns if the struct field is left out of the JSON encoding by the omitempty option,
+// using the same notion of an empty value as encoding/json.
+func omitted(t reflect.Type, index []int, field reflect.Value) bool {
+	if !tagOption(t, index, "omitempty") {
+		return false
+	}
+
+	switch field.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return field.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return field.IsZero()
+	default:
+		return false
+	}
+}
+
+// tagOption returns if the `json` tag of the struct field has the option, such as omitempty.
+func tagOption(t reflect.Type, index []int, option string) bool {
+	_, opts, _ := strings.Cut(t.FieldByIndex(index).Tag.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package express
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Address struct {
+	City    string   `json:"city"`
+	Zip     *string  `json:"zip,omitempty"`
+	Lines   []string `json:"lines"`
+	private string
+}
+
+type Audit struct {
+	Created time.Time `json:"created"`
+}
+
+type Company struct {
+	Audit
+	Name      string         `json:"name"`
+	Employees int            `json:"employees"`
+	Public    bool           `json:"public"`
+	Address   *Address       `json:"address"`
+	Labels    map[string]int `json:"labels"`
+	Ignored   string         `json:"-"`
+	Untagged  uint8
+}
+
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next"`
+}
+
+type recursive struct {
+	*recursive
+	Name string `json:"name"`
+}
+
+type money struct {
+	cents int
+}
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"amount":%d.%02d}`, m.cents/100, m.cents%100)), nil
+}
+
+type level int
+
+func (l *level) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+type Optional struct {
+	A     string         `json:"a,omitempty"`
+	B     string         `json:"b"`
+	Tags  []string       `json:"tags,omitempty"`
+	Audit Audit          `json:"audit,omitempty"`
+	Meta  map[string]int `json:"meta,omitempty"`
+}
+
+type Account struct {
+	Balance money `json:"balance"`
+	Level   level `json:"level"`
+	Count   int   `json:"count,string"`
+	Active  bool  `json:"active,string"`
+}
+
+func TestCalculateSource(t *testing.T) {
+	assert := require.New(t)
+	company := Company{
+		Audit:     Audit{Created: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		Name:      "MyCompany",
+		Employees: 50,
+		Public:    true,
+		Address:   &Address{City: "Berlin", Lines: []string{"a", "b"}},
+		Labels:    map[string]int{"tier": 1},
+		Ignored:   "ignored",
+		Untagged:  7,
+	}
+	cycle := &node{Name: "a"}
+	cycle.Next = cycle
+	account := &Account{Balance: money{cents: 1250}, Level: 3, Count: 5, Active: true}
+	tests := []struct {
+		name     string
+		exp      string
+		src      Source
+		expected any
+	}{
+		{
+			name:     "json source",
+			exp:      `.properties.employees > 20`,
+			src:      JSONSource(`{"properties":{"employees":50}}`),
+			expected: true,
+		},
+		{
+			name:     "map source nested",
+			exp:      `.properties.employees > 20 && .tags.1 == "b"`,
+			src:      MapSource{"properties": map[string]any{"employees": 50}, "tags": []string{"a", "b"}},
+			expected: true,
+		},
+		{
+			name:     "map source missing",
+			exp:      `.properties.missing`,
+			src:      MapSource{"properties": map[string]any{}},
+			expected: nil,
+		},
+		{
+			name:     "map source array",
+			exp:      `.tags`,
+			src:      MapSource{"tags": []int{1, 2}},
+			expected: []any{1.0, 2.0},
+		},
+		{
+			name:     "struct source json tags",
+			exp:      `.name + " " + .address.city`,
+			src:      StructSource(company),
+			expected: "MyCompany Berlin",
+		},
+		{
+			name:     "struct source pointer",
+			exp:      `.employees >= 50 && .public && .Untagged == 7`,
+			src:      StructSource(&company),
+			expected: true,
+		},
+		{
+			name:     "struct source embedded",
+			exp:      `.created > COERCE "2022-01-01" _datetime_`,
+			src:      StructSource(company),
+			expected: true,
+		},
+		{
+			name:     "struct source datetime coercion",
+			exp:      `COERCE .created _datetime_`,
+			src:      StructSource(company),
+			expected: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "struct source ignored field",
+			exp:      `.Ignored == NULL && .private == NULL`,
+			src:      StructSource(company),
+			expected: true,
+		},
+		{
+			name:     "struct source nested object",
+			exp:      `.address`,
+			src:      StructSource(company),
+			expected: map[string]any{"city": "Berlin", "lines": []any{"a", "b"}},
+		},
+		{
+			name:     "struct source map",
+			exp:      `.labels.tier == 1 && "b" IN .address.lines`,
+			src:      StructSource(company),
+			expected: true,
+		},
+		{
+			name:     "struct source cyclic pointer",
+			exp:      `.next`,
+			src:      StructSource(cycle),
+			expected: map[string]any{"name": "a", "next": nil},
+		},
+		{
+			name:     "map source cyclic map",
+			exp:      `.self.self`,
+			src:      func() Source { m := MapSource{"a": 1.0}; m["self"] = map[string]any(m); return m }(),
+			expected: map[string]any{"a": 1.0, "self": nil},
+		},
+		{
+			name:     "struct source embedding itself",
+			exp:      `.name`,
+			src:      StructSource(recursive{Name: "x"}),
+			expected: "x",
+		},
+		{
+			name:     "struct source json marshaler",
+			exp:      `.balance.amount == 12.5 && .level == "***"`,
+			src:      StructSource(account),
+			expected: true,
+		},
+		{
+			name:     "struct source string option",
+			exp:      `.count == "5" && .active == "true"`,
+			src:      StructSource(account),
+			expected: true,
+		},
+		{
+			name:     "struct source nested options",
+			exp:      `.account`,
+			src:      MapSource{"account": account},
+			expected: map[string]any{"balance": map[string]any{"amount": 12.5}, "level": "***", "count": "5", "active": "true"},
+		},
+		{
+			name:     "custom expression",
+			exp:      `COERCE .name _star_`,
+			src:      StructSource(company),
+			expected: "*********",
+		},
+	}
+
+	guard := Coercions.Lock()
+	guard.T["_star_"] = func(_ *Parser, constEligible bool, expression Expression) (stillConstEligible bool, e Expression, err error) {
+		return constEligible, &Star{expression}, nil
+	}
+	guard.Unlock()
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			got, err := CalculateSource(ex, tc.src)
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestStructSourceOmitEmpty(t *testing.T) {
+	values := []Optional{
+		{},
+		{A: "a", Tags: []string{}, Meta: map[string]int{}},
+		{A: "a", B: "b", Tags: []string{"x"}, Meta: map[string]int{"m": 1}},
+	}
+	expressions := []string{
+		`EXISTS .a`,
+		`.a IS MISSING`,
+		`TYPEOF(.a)`,
+		`.b == ""`,
+		`EXISTS .tags`,
+		`EXISTS .audit`,
+		`.meta IS MISSING`,
+	}
+
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+
+		for _, exp := range expressions {
+			t.Run(fmt.Sprintf("%s %s", data, exp), func(t *testing.T) {
+				assert := require.New(t)
+				ex, err := Parse([]byte(exp))
+				assert.NoError(err)
+
+				expected, err := CalculateSource(ex, JSONSource(data))
+				assert.NoError(err)
+
+				got, err := CalculateSource(ex, StructSource(value))
+				assert.NoError(err)
+				assert.Equal(expected, got)
+			})
+		}
+	}
+}
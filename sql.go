@@ -0,0 +1,654 @@
+package express
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Postgres emits JSONB operators for selectors, eg. `data->'a'->>'b'`.
+	Postgres Dialect = iota
+	// SQLite emits JSON1 functions for selectors, eg. `json_extract(data, '$.a.b')`.
+	SQLite
+)
+
+const (
+	sqlUnknown sqlType = iota
+	sqlText
+	sqlNumeric
+	sqlBool
+	sqlTimestamp
+	sqlJSON
+)
+
+// Dialect is the SQL dialect an Expression is transpiled to.
+type Dialect uint8
+
+// sqlType is the statically known SQL type of an expression.
+type sqlType uint8
+
+// SQLOptions configures how an Expression is transpiled to SQL.
+type SQLOptions struct {
+	// Dialect is the SQL dialect to emit.
+	Dialect Dialect
+	// Column is the JSON column selectors are resolved against, it is written verbatim and defaults to `data`.
+	Column string
+}
+
+// ToSQL transpiles a parsed Expression into a parameterized SQL WHERE clause
+// and the arguments for its placeholders.
+//
+// Selectors are resolved against the JSON column configured in the options
+// and the semantics of express are preserved where SQL differs, eg. BETWEEN is exclusive
+// and `!=` uses `IS DISTINCT FROM` so that missing values compare the same as they do in express.
+//
+// Will return ErrUnsupportedTranspile for constructs that have no SQL equivalent.
+func ToSQL(e Expression, opts SQLOptions) (where string, args []any, err error) {
+	if opts.Column == "" {
+		opts.Column = "data"
+	}
+
+	w := &sqlWriter{opts: opts}
+	if where, err = w.expr(e, sqlBool); err != nil {
+		return "", nil, err
+	}
+
+	return where, w.args, nil
+}
+
+type sqlWriter struct {
+	opts SQLOptions
+	args []any
+}
+
+func (w *sqlWriter) unsupported(s string) error {
+	return ErrUnsupportedTranspile{target: "SQL", s: s}
+}
+
+func (w *sqlWriter) expr(e Expression, want sqlType) (string, error) {
+	switch t := e.(type) {
+	case selectorPath:
+		return w.selector(t.s, want)
+	case null:
+		return "NULL", nil
+	case str, num, boolean, coercedConstant, array:
+		value, ok := literalValue(t)
+		if !ok {
+			return "", w.unsupported("array containing non constant values")
+		}
+		return w.param(value, want), nil
+	case eq:
+		return w.equals(t.left, t.right, false)
+	case not:
+		switch inner := t.value.(type) {
+		case eq:
+			return w.equals(inner.left, inner.right, true)
+		case exists:
+			return w.exists(inner.s, true)
+		}
+
+		value, err := w.expr(t.value, sqlBool)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + value, nil
+	case gt:
+		return w.comparison(">", t.left, t.right)
+	case gte:
+		return w.comparison(">=", t.left, t.right)
+	case lt:
+		return w.comparison("<", t.left, t.right)
+	case lte:
+		return w.comparison("<=", t.left, t.right)
+	case and:
+		return w.binary("AND", t.left, t.right, sqlBool)
+	case or:
+		return w.binary("OR", t.left, t.right, sqlBool)
+	case add:
+		if sqlTypeOf(t) == sqlText {
+			return w.binary("||", t.left, t.right, sqlText)
+		}
+		return w.binary("+", t.left, t.right, sqlNumeric)
+	case sub:
+		return w.binary("-", t.left, t.right, sqlNumeric)
+	case multi:
+		return w.binary("*", t.left, t.right, sqlNumeric)
+	case div:
+		return w.binary("/", t.left, t.right, sqlNumeric)
+	case exists:
+		return w.exists(t.s, false)
+	case between:
+		return w.between(t)
+	case in:
+		return w.in(t.left, t.right)
+	case contains:
+		return w.contains(t.left, t.right)
+	case containsAny:
+		return w.containsEach("OR", "CONTAINS_ANY", t.left, t.right)
+	case containsAll:
+		return w.containsEach("AND", "CONTAINS_ALL", t.left, t.right)
+	case startsWith:
+		return w.affix(t.left, t.right, true)
+	case endsWith:
+		return w.affix(t.left, t.right, false)
+	case coerceDateTime:
+		return w.expr(t.value, sqlTimestamp)
+	case coerceLowercase:
+		value, err := w.expr(t.value, sqlText)
+		if err != nil {
+			return "", err
+		}
+		return "LOWER(" + value + ")", nil
+	case coerceUppercase:
+		value, err := w.expr(t.value, sqlText)
+		if err != nil {
+			return "", err
+		}
+		return "UPPER(" + value + ")", nil
+	case coerceString:
+		return w.cast(t.value, sqlText, "TEXT")
+	case coerceNumber:
+		if w.opts.Dialect == SQLite {
+			return w.cast(t.value, sqlNumeric, "REAL")
+		}
+		return w.cast(t.value, sqlNumeric, "NUMERIC")
+	case coerceTitle:
+		return "", w.unsupported("COERCE _title_")
+	case coerceSubstr:
+		return "", w.unsupported("COERCE _substr_")
+	default:
+		return "", w.unsupported(format(e))
+	}
+}
+
+// cast converts an expression into the wanted type, selectors are extracted
+// directly as that type while all other expressions are wrapped in a CAST.
+func (w *sqlWriter) cast(e Expression, want sqlType, name string) (string, error) {
+	if sqlTypeOf(e) == sqlUnknown {
+		return w.expr(e, want)
+	}
+
+	value, err := w.expr(e, sqlTypeOf(e))
+	if err != nil {
+		return "", err
+	}
+	return "CAST(" + value + " AS " + name + ")", nil
+}
+
+func (w *sqlWriter) binary(op string, left, right Expression, want sqlType) (string, error) {
+	l, err := w.expr(left, want)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := w.expr(right, want)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// comparison compares both sides as the type known for either of them or as JSON
+// when neither type is known until the data is seen.
+func (w *sqlWriter) comparison(op string, left, right Expression) (string, error) {
+	return w.binary(op, left, right, operandType(left, right))
+}
+
+func (w *sqlWriter) equals(left, right Expression, negate bool) (string, error) {
+	_, leftNull := left.(null)
+	_, rightNull := right.(null)
+	if leftNull || rightNull {
+		if leftNull {
+			left = right
+		}
+
+		value, err := w.expr(left, sqlText)
+		if err != nil {
+			return "", err
+		}
+
+		if negate {
+			return "(" + value + " IS NOT NULL)", nil
+		}
+		return "(" + value + " IS NULL)", nil
+	}
+
+	if !negate {
+		return w.comparison("=", left, right)
+	}
+
+	if w.opts.Dialect == SQLite {
+		return w.comparison("IS NOT", left, right)
+	}
+	return w.comparison("IS DISTINCT FROM", left, right)
+}
+
+// exists checks if the selector path is present, the JSON value rather than its text
+// being selected so that a present null is not NULL.
+func (w *sqlWriter) exists(path string, negate bool) (value string, err error) {
+	if w.opts.Dialect == SQLite {
+		p, err := w.sqlitePath(path)
+		if err != nil {
+			return "", err
+		}
+		value = "json_type(" + w.opts.Column + ", " + p + ")"
+	} else if value, err = w.selector(path, sqlJSON); err != nil {
+		return "", err
+	}
+
+	if negate {
+		return "(" + value + " IS NULL)", nil
+	}
+	return "(" + value + " IS NOT NULL)", nil
+}
+
+func (w *sqlWriter) between(b between) (string, error) {
+	want := operandType(b.value, b.left)
+	if want == sqlJSON {
+		want = operandType(b.value, b.right)
+	}
+
+	// express BETWEEN is exclusive of both bounds unlike SQL BETWEEN
+	gt, err := w.binary(">", b.value, b.left, want)
+	if err != nil {
+		return "", err
+	}
+
+	lt, err := w.binary("<", b.value, b.right, want)
+	if err != nil {
+		return "", err
+	}
+	return "(" + gt + " AND " + lt + ")", nil
+}
+
+func (w *sqlWriter) in(left, right Expression) (string, error) {
+	if values, ok := literalValue(right); ok {
+		arr, ok := values.([]any)
+		if !ok {
+			return "", w.unsupported("IN with a non array value")
+		}
+
+		if len(arr) == 0 {
+			return "FALSE", nil
+		}
+
+		want := sqlTypeOf(left)
+		if want == sqlUnknown {
+			want = valueSQLType(arr[0])
+		}
+
+		for _, v := range arr {
+			if t := valueSQLType(v); t != want || t == sqlJSON || t == sqlUnknown {
+				return "", w.unsupported("IN with mixed or non scalar array values")
+			}
+		}
+
+		value, err := w.expr(left, want)
+		if err != nil {
+			return "", err
+		}
+
+		placeholders := make([]string, 0, len(arr))
+		for _, v := range arr {
+			placeholders = append(placeholders, w.param(v, want))
+		}
+		return "(" + value + " IN (" + strings.Join(placeholders, ", ") + "))", nil
+	}
+
+	sel, ok := right.(selectorPath)
+	if !ok {
+		return "", w.unsupported("IN with a non selector or array value")
+	}
+	return w.arrayContains(sel, left)
+}
+
+// arrayContains checks if the JSON array found at the selector contains an element equal to the value.
+func (w *sqlWriter) arrayContains(sel selectorPath, value Expression) (string, error) {
+	v, ok := literalValue(value)
+	if !ok {
+		return "", w.unsupported("array membership of a non constant value")
+	}
+
+	if w.opts.Dialect == SQLite {
+		path, err := w.sqlitePath(sel.s)
+		if err != nil {
+			return "", err
+		}
+		return "EXISTS (SELECT 1 FROM json_each(" + w.opts.Column + ", " + path + ") WHERE value = " + w.param(v, valueSQLType(v)) + ")", nil
+	}
+
+	arr, err := w.selector(sel.s, sqlJSON)
+	if err != nil {
+		return "", err
+	}
+	return "(" + arr + " @> " + w.param([]any{v}, sqlJSON) + ")", nil
+}
+
+func (w *sqlWriter) contains(left, right Expression) (string, error) {
+	if values, ok := literalValue(left); ok {
+		if _, ok := values.([]any); ok {
+			r, err := w.expr(right, sqlJSON)
+			if err != nil {
+				return "", err
+			}
+
+			if w.opts.Dialect == SQLite {
+				return "(" + r + " IN (SELECT value FROM json_each(" + w.param(values, sqlJSON) + ")))", nil
+			}
+			return "(" + w.param(values, sqlJSON) + " @> jsonb_build_array(" + r + "))", nil
+		}
+	}
+
+	sel, ok := left.(selectorPath)
+	if !ok {
+		return w.substring(left, right)
+	}
+
+	// the selector can resolve to either an array or a string, each having different CONTAINS semantics
+	member, err := w.arrayContains(sel, right)
+	if err != nil {
+		return "", err
+	}
+
+	substr, err := w.substring(left, right)
+	if err != nil {
+		return "", err
+	}
+
+	if w.opts.Dialect == SQLite {
+		path, err := w.sqlitePath(sel.s)
+		if err != nil {
+			return "", err
+		}
+		return "(CASE json_type(" + w.opts.Column + ", " + path + ") WHEN 'array' THEN " + member + " ELSE " + substr + " END)", nil
+	}
+
+	arr, err := w.selector(sel.s, sqlJSON)
+	if err != nil {
+		return "", err
+	}
+	return "(CASE jsonb_typeof(" + arr + ") WHEN 'array' THEN " + member + " ELSE " + substr + " END)", nil
+}
+
+func (w *sqlWriter) containsEach(op, name string, left, right Expression) (string, error) {
+	values, ok := literalValue(right)
+	if !ok {
+		return "", w.unsupported(name + " with a non constant value")
+	}
+
+	arr, ok := values.([]any)
+	if !ok {
+		return "", w.unsupported(name + " with a non array value")
+	}
+
+	if len(arr) == 0 {
+		if op == "AND" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+
+	clauses := make([]string, 0, len(arr))
+	for _, v := range arr {
+		clause, err := w.contains(left, coercedConstant{value: v})
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return "(" + strings.Join(clauses, " "+op+" ") + ")", nil
+}
+
+// substring checks if the left string contains the right string.
+func (w *sqlWriter) substring(left, right Expression) (string, error) {
+	l, err := w.expr(left, sqlText)
+	if err != nil {
+		return "", err
+	}
+
+	if w.opts.Dialect == SQLite {
+		// SQLite LIKE is case insensitive, instr is not
+		r, err := w.expr(right, sqlText)
+		if err != nil {
+			return "", err
+		}
+		return "(instr(" + l + ", " + r + ") > 0)", nil
+	}
+
+	if v, ok := literalValue(right); ok {
+		s, ok := v.(string)
+		if !ok {
+			return "", w.unsupported("CONTAINS with a non string value")
+		}
+		return "(" + l + " LIKE " + w.param("%"+escapeLike(s)+"%", sqlText) + ` ESCAPE '\')`, nil
+	}
+
+	r, err := w.expr(right, sqlText)
+	if err != nil {
+		return "", err
+	}
+	return "(strpos(" + l + ", " + r + ") > 0)", nil
+}
+
+// affix checks if the left string starts or ends with the right string.
+func (w *sqlWriter) affix(left, right Expression, prefix bool) (string, error) {
+	l, err := w.expr(left, sqlText)
+	if err != nil {
+		return "", err
+	}
+
+	if v, ok := literalValue(right); ok && w.opts.Dialect == Postgres {
+		s, ok := v.(string)
+		if !ok {
+			return "", w.unsupported("STARTSWITH or ENDSWITH with a non string value")
+		}
+
+		if prefix {
+			s = escapeLike(s) + "%"
+		} else {
+			s = "%" + escapeLike(s)
+		}
+		return "(" + l + " LIKE " + w.param(s, sqlText) + ` ESCAPE '\')`, nil
+	}
+
+	if prefix {
+		r, err := w.expr(right, sqlText)
+		if err != nil {
+			return "", err
+		}
+
+		if w.opts.Dialect == SQLite {
+			return "(instr(" + l + ", " + r + ") = 1)", nil
+		}
+		return "starts_with(" + l + ", " + r + ")", nil
+	}
+
+	// operands are repeated and so are written once per use to keep placeholders in order
+	if w.opts.Dialect == SQLite {
+		ops, err := w.exprs(sqlText, left, right, right)
+		if err != nil {
+			return "", err
+		}
+		return "(substr(" + l + ", length(" + ops[0] + ") - length(" + ops[1] + ") + 1) = " + ops[2] + ")", nil
+	}
+
+	ops, err := w.exprs(sqlText, right, right)
+	if err != nil {
+		return "", err
+	}
+	return "(right(" + l + ", length(" + ops[0] + ")) = " + ops[1] + ")", nil
+}
+
+// exprs writes each expression in order as the wanted type.
+func (w *sqlWriter) exprs(want sqlType, es ...Expression) ([]string, error) {
+	out := make([]string, 0, len(es))
+	for _, e := range es {
+		s, err := w.expr(e, want)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (w *sqlWriter) selector(path string, want sqlType) (string, error) {
+	if w.opts.Dialect == SQLite {
+		p, err := w.sqlitePath(path)
+		if err != nil {
+			return "", err
+		}
+
+		extract := "json_extract(" + w.opts.Column + ", " + p + ")"
+		if want == sqlTimestamp {
+			return "datetime(" + extract + ")", nil
+		}
+		return extract, nil
+	}
+
+	segments, ok := selectorSegments(path)
+	if !ok {
+		return "", w.unsupported("selector path ." + path)
+	}
+
+	// the path to the parent of the final segment, which is selected as jsonb with `->` or as text with `->>`
+	var b strings.Builder
+	b.WriteString(w.opts.Column)
+	for _, segment := range segments[:len(segments)-1] {
+		b.WriteString("->" + pathSegment(segment))
+	}
+	parent, last := b.String(), pathSegment(segments[len(segments)-1])
+
+	switch want {
+	case sqlJSON:
+		return parent + "->" + last, nil
+	case sqlNumeric:
+		// casting any other type, such as a string, to numeric fails so other types are treated as null
+		return "CASE WHEN jsonb_typeof(" + parent + "->" + last + ") = 'number' THEN (" + parent + "->>" + last + ")::numeric END", nil
+	case sqlBool:
+		return "CASE WHEN jsonb_typeof(" + parent + "->" + last + ") = 'boolean' THEN (" + parent + "->>" + last + ")::boolean END", nil
+	case sqlTimestamp:
+		return "(" + parent + "->>" + last + ")::timestamptz", nil
+	default:
+		return parent + "->>" + last, nil
+	}
+}
+
+// pathSegment returns the segment of a selector path as a Postgres array index or quoted object key.
+func pathSegment(segment string) string {
+	if isIndex(segment) {
+		return segment
+	}
+	return quoteSQL(segment)
+}
+
+func (w *sqlWriter) sqlitePath(path string) (string, error) {
+	segments, ok := selectorSegments(path)
+	if !ok {
+		return "", w.unsupported("selector path ." + path)
+	}
+
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, segment := range segments {
+		switch {
+		case isIndex(segment):
+			b.WriteString("[" + segment + "]")
+		case strings.ContainsRune(segment, '"'):
+			return "", w.unsupported("selector path ." + path)
+		default:
+			b.WriteString(`."` + segment + `"`)
+		}
+	}
+	return quoteSQL(b.String()), nil
+}
+
+// param adds a placeholder argument for the value.
+func (w *sqlWriter) param(value any, want sqlType) string {
+	if valueSQLType(value) == sqlJSON || (want == sqlJSON && w.opts.Dialect == Postgres) {
+		b, _ := json.Marshal(value)
+		w.args = append(w.args, string(b))
+		if w.opts.Dialect == SQLite {
+			return "json(" + w.placeholder() + ")"
+		}
+		return w.placeholder() + "::jsonb"
+	}
+
+	if t, ok := value.(time.Time); ok && w.opts.Dialect == SQLite {
+		value = t.UTC().Format(time.DateTime)
+	}
+	w.args = append(w.args, value)
+	return w.placeholder()
+}
+
+func (w *sqlWriter) placeholder() string {
+	if w.opts.Dialect == SQLite {
+		return "?"
+	}
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// operandType returns the type both operands of a comparison are converted to.
+func operandType(left, right Expression) sqlType {
+	if t := sqlTypeOf(left); t != sqlUnknown {
+		return t
+	}
+
+	if t := sqlTypeOf(right); t != sqlUnknown {
+		return t
+	}
+	return sqlJSON
+}
+
+// sqlTypeOf returns the statically known SQL type of an expression,
+// selectors are unknown until resolved against the data.
+func sqlTypeOf(e Expression) sqlType {
+	switch t := e.(type) {
+	case str, coerceString, coerceLowercase, coerceUppercase, coerceTitle, coerceSubstr:
+		return sqlText
+	case num, sub, multi, div, coerceNumber:
+		return sqlNumeric
+	case boolean, eq, gt, gte, lt, lte, and, or, not, in, between, contains, containsAny, containsAll, startsWith, endsWith:
+		return sqlBool
+	case coerceDateTime:
+		return sqlTimestamp
+	case array:
+		return sqlJSON
+	case coercedConstant:
+		return valueSQLType(t.value)
+	case add:
+		if l := sqlTypeOf(t.left); l != sqlUnknown {
+			return l
+		}
+		return sqlTypeOf(t.right)
+	default:
+		return sqlUnknown
+	}
+}
+
+func valueSQLType(value any) sqlType {
+	switch value.(type) {
+	case string:
+		return sqlText
+	case float64:
+		return sqlNumeric
+	case bool:
+		return sqlBool
+	case time.Time:
+		return sqlTimestamp
+	case []any, map[string]any:
+		return sqlJSON
+	default:
+		return sqlUnknown
+	}
+}
+
+func quoteSQL(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,235 @@
+package express
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSQL(t *testing.T) {
+	assert := require.New(t)
+	tests := []struct {
+		name     string
+		exp      string
+		opts     SQLOptions
+		expected string
+		args     []any
+		err      error
+	}{
+		{
+			name:     "postgres selector eq string",
+			exp:      `.a.b == "x"`,
+			expected: `(data->'a'->>'b' = $1)`,
+			args:     []any{"x"},
+		},
+		{
+			name:     "postgres selector gt number",
+			exp:      `.properties.employees > 20`,
+			expected: `(CASE WHEN jsonb_typeof(data->'properties'->'employees') = 'number' THEN (data->'properties'->>'employees')::numeric END > $1)`,
+			args:     []any{float64(20)},
+		},
+		{
+			name:     "postgres selector array index",
+			exp:      `.items.0.id == 1`,
+			expected: `(CASE WHEN jsonb_typeof(data->'items'->0->'id') = 'number' THEN (data->'items'->0->>'id')::numeric END = $1)`,
+			args:     []any{float64(1)},
+		},
+		{
+			name:     "postgres custom column",
+			exp:      `.a == true`,
+			opts:     SQLOptions{Column: "doc"},
+			expected: `(CASE WHEN jsonb_typeof(doc->'a') = 'boolean' THEN (doc->>'a')::boolean END = $1)`,
+			args:     []any{true},
+		},
+		{
+			name:     "postgres selector eq selector",
+			exp:      `.a == .b`,
+			expected: `(data->'a' = data->'b')`,
+		},
+		{
+			name:     "postgres not equals",
+			exp:      `.a != "x"`,
+			expected: `(data->>'a' IS DISTINCT FROM $1)`,
+			args:     []any{"x"},
+		},
+		{
+			name:     "postgres is null",
+			exp:      `.a == NULL`,
+			expected: `(data->>'a' IS NULL)`,
+		},
+		{
+			name:     "postgres is not null",
+			exp:      `.a != NULL && .a > 1`,
+			expected: `((data->>'a' IS NOT NULL) AND (CASE WHEN jsonb_typeof(data->'a') = 'number' THEN (data->>'a')::numeric END > $1))`,
+			args:     []any{float64(1)},
+		},
+		{
+			name:     "postgres or not",
+			exp:      `.a || !.b`,
+			expected: `(CASE WHEN jsonb_typeof(data->'a') = 'boolean' THEN (data->>'a')::boolean END OR NOT CASE WHEN jsonb_typeof(data->'b') = 'boolean' THEN (data->>'b')::boolean END)`,
+		},
+		{
+			name:     "postgres in",
+			exp:      `.region IN ["eu", "us"]`,
+			expected: `(data->>'region' IN ($1, $2))`,
+			args:     []any{"eu", "us"},
+		},
+		{
+			name:     "postgres value in selector",
+			exp:      `"a" IN .tags`,
+			expected: `(data->'tags' @> $1::jsonb)`,
+			args:     []any{`["a"]`},
+		},
+		{
+			name:     "postgres contains",
+			exp:      `.name CONTAINS "50%"`,
+			expected: `(CASE jsonb_typeof(data->'name') WHEN 'array' THEN (data->'name' @> $1::jsonb) ELSE (data->>'name' LIKE $2 ESCAPE '\') END)`,
+			args:     []any{`["50%"]`, `%50\%%`},
+		},
+		{
+			name:     "postgres contains all",
+			exp:      `COERCE .name _lowercase_ CONTAINS_ALL ["a", "b"]`,
+			expected: `((LOWER(data->>'name') LIKE $1 ESCAPE '\') AND (LOWER(data->>'name') LIKE $2 ESCAPE '\'))`,
+			args:     []any{"%a%", "%b%"},
+		},
+		{
+			name:     "postgres startswith",
+			exp:      `.name STARTSWITH "ab_"`,
+			expected: `(data->>'name' LIKE $1 ESCAPE '\')`,
+			args:     []any{`ab\_%`},
+		},
+		{
+			name:     "postgres endswith selector",
+			exp:      `.name ENDSWITH .suffix`,
+			expected: `(right(data->>'name', length(data->>'suffix')) = data->>'suffix')`,
+		},
+		{
+			name:     "postgres between is exclusive",
+			exp:      `.a BETWEEN 1 10`,
+			expected: `((CASE WHEN jsonb_typeof(data->'a') = 'number' THEN (data->>'a')::numeric END > $1) AND (CASE WHEN jsonb_typeof(data->'a') = 'number' THEN (data->>'a')::numeric END < $2))`,
+			args:     []any{float64(1), float64(10)},
+		},
+		{
+			name:     "postgres datetime",
+			exp:      `COERCE .created _datetime_ > COERCE "2022-01-02" _datetime_`,
+			expected: `((data->>'created')::timestamptz > $1)`,
+			args:     []any{time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "postgres concat",
+			exp:      `.first + " " + .last == "Joey Bloggs"`,
+			expected: `(((data->>'first' || $1) || data->>'last') = $2)`,
+			args:     []any{" ", "Joey Bloggs"},
+		},
+		{
+			name:     "postgres number coercion",
+			exp:      `COERCE .a _number_ > 1`,
+			expected: `(CASE WHEN jsonb_typeof(data->'a') = 'number' THEN (data->>'a')::numeric END > $1)`,
+			args:     []any{float64(1)},
+		},
+		{
+			name:     "sqlite selector eq",
+			exp:      `.a.b == "x" && .c.1 > 2`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `((json_extract(data, '$."a"."b"') = ?) AND (json_extract(data, '$."c"[1]') > ?))`,
+			args:     []any{"x", float64(2)},
+		},
+		{
+			name:     "sqlite value in selector",
+			exp:      `"a" IN .tags`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `EXISTS (SELECT 1 FROM json_each(data, '$."tags"') WHERE value = ?)`,
+			args:     []any{"a"},
+		},
+		{
+			name:     "sqlite contains",
+			exp:      `.name CONTAINS "ea"`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `(CASE json_type(data, '$."name"') WHEN 'array' THEN EXISTS (SELECT 1 FROM json_each(data, '$."name"') WHERE value = ?) ELSE (instr(json_extract(data, '$."name"'), ?) > 0) END)`,
+			args:     []any{"ea", "ea"},
+		},
+		{
+			name:     "sqlite endswith",
+			exp:      `.name ENDSWITH "am"`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `(substr(json_extract(data, '$."name"'), length(json_extract(data, '$."name"')) - length(?) + 1) = ?)`,
+			args:     []any{"am", "am"},
+		},
+		{
+			name:     "sqlite datetime",
+			exp:      `COERCE .created _datetime_ BETWEEN COERCE "2022-01-01" _datetime_ COERCE "2022-01-30" _datetime_`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `((datetime(json_extract(data, '$."created"')) > ?) AND (datetime(json_extract(data, '$."created"')) < ?))`,
+			args:     []any{"2022-01-01 00:00:00", "2022-01-30 00:00:00"},
+		},
+		{
+			name:     "sqlite not equals",
+			exp:      `.a != 1`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `(json_extract(data, '$."a"') IS NOT ?)`,
+			args:     []any{float64(1)},
+		},
+		{
+			name:     "postgres exists",
+			exp:      `EXISTS .a.b`,
+			expected: `(data->'a'->'b' IS NOT NULL)`,
+		},
+		{
+			name:     "postgres missing",
+			exp:      `.a IS MISSING`,
+			expected: `(data->'a' IS NULL)`,
+		},
+		{
+			name:     "sqlite exists",
+			exp:      `EXISTS .a.b`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `(json_type(data, '$."a"."b"') IS NOT NULL)`,
+		},
+		{
+			name:     "sqlite missing",
+			exp:      `.a IS MISSING`,
+			opts:     SQLOptions{Dialect: SQLite},
+			expected: `(json_type(data, '$."a"') IS NULL)`,
+		},
+		{
+			name: "unsupported gjson wildcard",
+			exp:  `.items.#.id == 1`,
+			err:  ErrUnsupportedTranspile{target: "SQL", s: "selector path .items.#.id"},
+		},
+		{
+			name: "unsupported title coercion",
+			exp:  `COERCE .a _title_ == "A"`,
+			err:  ErrUnsupportedTranspile{target: "SQL", s: "COERCE _title_"},
+		},
+		{
+			name: "unsupported contains any characters",
+			exp:  `.a CONTAINS_ANY "abc"`,
+			err:  ErrUnsupportedTranspile{target: "SQL", s: "CONTAINS_ANY with a non array value"},
+		},
+		{
+			name: "unsupported function",
+			exp:  `LEN(.a) > 1`,
+			err:  ErrUnsupportedTranspile{target: "SQL", s: "LEN(.a)"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			where, args, err := ToSQL(ex, tc.opts)
+			if tc.err != nil {
+				assert.Equal(tc.err, err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, where)
+			assert.Equal(tc.args, args)
+		})
+	}
+}
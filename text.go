@@ -0,0 +1,325 @@
+package express
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+var _ Expression = (*fold)(nil)
+
+// fold case folds strings, including those within arrays, for the case-insensitive
+// operators such as `~=` and ICONTAINS, leaving any other value unchanged.
+type fold struct {
+	value Expression
+}
+
+func (f fold) Calculate(src []byte) (any, error) {
+	return newEnvironment(JSONSource(src)).eval(f)
+}
+
+func (f fold) eval(env *environment) (any, error) {
+	value, err := env.eval(f.value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return cases.Fold().String(v), nil
+	case []any:
+		folded := make([]any, 0, len(v))
+		caser := cases.Fold()
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				elem = caser.String(s)
+			}
+			folded = append(folded, elem)
+		}
+		return folded, nil
+	default:
+		return value, nil
+	}
+}
+
+// unfold returns the operands of a case-insensitive operator, reporting false if they are not case folded.
+func unfold(left, right Expression) (Expression, Expression, bool) {
+	l, lok := left.(fold)
+	r, rok := right.(fold)
+	if !lok || !rok {
+		return nil, nil, false
+	}
+	return l.value, r.value, true
+}
+
+// collators pools a Collator for each collation, as they are not safe for concurrent use.
+var collators sync.Map
+
+// compare compares the strings using the collation, if any, otherwise bytewise.
+func (o ParseOptions) compare(a, b string) int {
+	if o.Collation == "" {
+		return strings.Compare(a, b)
+	}
+
+	pool, found := collators.Load(o.Collation)
+	if !found {
+		tag := language.Make(o.Collation)
+		pool, _ = collators.LoadOrStore(o.Collation, &sync.Pool{
+			New: func() any {
+				return collate.New(tag)
+			},
+		})
+	}
+
+	c := pool.(*sync.Pool).Get().(*collate.Collator)
+	defer pool.(*sync.Pool).Put(c)
+	return c.CompareString(a, b)
+}
+
+// length returns the number of characters in a string, elements in an array or keys in an object.
+func length(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []any:
+		return float64(len(v)), nil
+	case map[string]any:
+		return float64(len(v)), nil
+	default:
+		return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "string, array or object"}
+	}
+}
+
+// trim returns a function trimming the characters of its optional second argument, or otherwise whitespace.
+func trim(cutset func(s, cutset string) string, space func(s string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if nullArg(args) {
+			return nil, nil
+		}
+
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) == 1 {
+			return space(s), nil
+		}
+
+		chars, err := stringArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return cutset(s, chars), nil
+	}
+}
+
+func trimLeftSpace(s string) string {
+	return strings.TrimLeftFunc(s, unicode.IsSpace)
+}
+
+func trimRightSpace(s string) string {
+	return strings.TrimRightFunc(s, unicode.IsSpace)
+}
+
+func split(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sep, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(s, sep)
+	arr := make([]any, 0, len(parts))
+	for _, part := range parts {
+		arr = append(arr, part)
+	}
+	return arr, nil
+}
+
+func join(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	arr, ok := args[0].([]any)
+	if !ok {
+		return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "array of strings"}
+	}
+
+	sep, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, 0, len(arr))
+	for _, v := range arr {
+		s, ok := v.(string)
+		if !ok {
+			return nil, ErrInvalidArgument{Index: 0, Value: args[0], Expected: "array of strings"}
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func replace(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, err := stringArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(s, old, replacement), nil
+}
+
+// indexOf returns a function returning the character index of the first occurrence of the substring, or the index
+// of the first element of an array equal to the value within the tolerance, including null, or -1 if not found.
+func indexOf(tolerance float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if arr, ok := args[0].([]any); ok {
+			return float64(indexOfElement(arr, args[1], tolerance)), nil
+		} else if nullArg(args) {
+			return nil, nil
+		}
+
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err := stringArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		i := strings.Index(s, sub)
+		if i < 0 {
+			return -1.0, nil
+		}
+		return float64(utf8.RuneCountInString(s[:i])), nil
+	}
+}
+
+// pad returns a function padding a string to a number of characters with its optional
+// third argument, or otherwise spaces, on the left or right.
+func pad(left bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if nullArg(args) {
+			return nil, nil
+		}
+
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		width, err := countArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		padding := " "
+		if len(args) == 3 {
+			if padding, err = stringArg(args, 2); err != nil {
+				return nil, err
+			} else if padding == "" {
+				return nil, ErrInvalidArgument{Index: 2, Value: args[2], Expected: "non-empty string"}
+			}
+		}
+
+		missing := width - utf8.RuneCountInString(s)
+		if missing <= 0 {
+			return s, nil
+		}
+
+		runes := []rune(strings.Repeat(padding, missing/utf8.RuneCountInString(padding)+1))[:missing]
+		if left {
+			return string(runes) + s, nil
+		}
+		return s + string(runes), nil
+	}
+}
+
+// padSize returns the length in bytes of the result of padding, zero when the arguments are invalid.
+func padSize(args []any) int {
+	s, _ := args[0].(string)
+	width, err := countArg(args, 1)
+	if err != nil {
+		return 0
+	}
+
+	padding := " "
+	if len(args) == 3 {
+		padding, _ = args[2].(string)
+	}
+
+	missing := width - utf8.RuneCountInString(s)
+	if missing <= 0 || padding == "" {
+		return len(s)
+	}
+	return len(s) + product(missing/utf8.RuneCountInString(padding)+1, len(padding))
+}
+
+// repeatSize returns the length in bytes of the result of repeat, zero when the arguments are invalid.
+func repeatSize(args []any) int {
+	s, _ := args[0].(string)
+	count, err := countArg(args, 1)
+	if err != nil {
+		return 0
+	}
+	return product(len(s), count)
+}
+
+// product multiplies the non-negative sizes, saturating rather than overflowing.
+func product(a, b int) int {
+	if b != 0 && a > (math.MaxInt-MaxResultLength)/b {
+		return math.MaxInt - MaxResultLength
+	}
+	return a * b
+}
+
+func repeat(args []any) (any, error) {
+	if nullArg(args) {
+		return nil, nil
+	}
+
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := countArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Repeat(s, count), nil
+}
@@ -0,0 +1,370 @@
+package express
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "equals",
+			exp:      `.a ~= "zürich"`,
+			src:      `{"a":"ZÜRICH"}`,
+			expected: true,
+		},
+		{
+			name:     "equals full case folding",
+			exp:      `.a ~= "STRASSE"`,
+			src:      `{"a":"Straße"}`,
+			expected: true,
+		},
+		{
+			name:     "equals different",
+			exp:      `.a ~= "zurich"`,
+			src:      `{"a":"Zürich"}`,
+			expected: false,
+		},
+		{
+			name:     "not equals",
+			exp:      `.a !~= "x"`,
+			src:      `{"a":"X"}`,
+			expected: false,
+		},
+		{
+			name:     "equals non string",
+			exp:      `.a ~= 1`,
+			src:      `{"a":1}`,
+			expected: true,
+		},
+		{
+			name:     "starts with",
+			exp:      `.a ISTARTSWITH "hel"`,
+			src:      `{"a":"HELLO"}`,
+			expected: true,
+		},
+		{
+			name:     "ends with",
+			exp:      `.a IENDSWITH "LO"`,
+			src:      `{"a":"hello"}`,
+			expected: true,
+		},
+		{
+			name:     "contains",
+			exp:      `.a ICONTAINS "ELL"`,
+			src:      `{"a":"hello"}`,
+			expected: true,
+		},
+		{
+			name:     "contains array",
+			exp:      `.tags ICONTAINS "Go"`,
+			src:      `{"tags":["GO","rust"]}`,
+			expected: true,
+		},
+		{
+			name:     "in",
+			exp:      `.a IIN ["X", "y"]`,
+			src:      `{"a":"Y"}`,
+			expected: true,
+		},
+		{
+			name:     "in not found",
+			exp:      `.a IIN ["X", "y"]`,
+			src:      `{"a":"z"}`,
+			expected: false,
+		},
+	})
+}
+
+func TestCaseInsensitiveFormat(t *testing.T) {
+	assert := require.New(t)
+	for _, exp := range []string{
+		`.a ~= "zürich"`,
+		`.a ~= "STRASSE"`,
+		`.a ~= "zurich"`,
+		`.a !~= "x"`,
+		`.a ~= 1`,
+		`.a ISTARTSWITH "hel"`,
+		`.a IENDSWITH "LO"`,
+		`.a ICONTAINS "ELL"`,
+		`.tags ICONTAINS "Go"`,
+		`.a IIN ["X", "y"]`,
+	} {
+		ex, err := Parse([]byte(exp))
+		assert.NoError(err)
+		assert.Equal(exp, format(ex))
+	}
+}
+
+func TestCollation(t *testing.T) {
+	tests := []struct {
+		name      string
+		exp       string
+		collation string
+		expected  any
+	}{
+		{name: "bytewise", exp: `"Zürich" < "apple"`, expected: true},
+		{name: "collated", exp: `"Zürich" < "apple"`, collation: "en", expected: false},
+		{name: "collated accent", exp: `"zebra" > "Äpfel"`, collation: "de", expected: true},
+		{name: "collated gte", exp: `"Äpfel" >= "apfel"`, collation: "de", expected: true},
+		{name: "collated lte", exp: `"apple" <= "Zürich"`, collation: "en", expected: true},
+		{name: "between german", exp: `"ö" BETWEEN "o" "p"`, collation: "de", expected: true},
+		{name: "between swedish", exp: `"ö" BETWEEN "o" "p"`, collation: "sv", expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := ParseWithOptions([]byte(tc.exp), ParseOptions{Collation: tc.collation})
+			assert.NoError(err)
+
+			got, err := ex.Calculate(nil)
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+
+	_, err := ParseWithOptions([]byte(`.a < "b"`), ParseOptions{Collation: "not a language"})
+	require.Error(t, err)
+}
+
+func TestStringFunctions(t *testing.T) {
+	runCalculateTests(t, []calculateTest{
+		{
+			name:     "len",
+			exp:      `LEN(.a)`,
+			src:      `{"a":"héllo"}`,
+			expected: 5.0,
+		},
+		{
+			name:     "len missing",
+			exp:      `LEN(.missing)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "len array",
+			exp:      `LEN(.a)`,
+			src:      `{"a":[1,2]}`,
+			expected: 2.0,
+		},
+		{
+			name: "len invalid",
+			exp:  `LEN(.a)`,
+			src:  `{"a":1}`,
+			err:  ErrInvalidArgument{Function: "LEN", Index: 0, Value: 1.0, Expected: "string, array or object", Span: Span{Start: 0, End: 7}},
+		},
+		{
+			name:     "trim",
+			exp:      `TRIM(.a)`,
+			src:      `{"a":"  x \t"}`,
+			expected: "x",
+		},
+		{
+			name:     "trim cutset",
+			exp:      `TRIM(.a, "-")`,
+			src:      `{"a":"--x--"}`,
+			expected: "x",
+		},
+		{
+			name:     "trim missing",
+			exp:      `TRIM(.missing)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "trim null cutset",
+			exp:      `TRIM(.a, .b)`,
+			src:      `{"a":"--x--","b":null}`,
+			expected: nil,
+		},
+		{
+			name:     "trim left",
+			exp:      `TRIM_LEFT(.a)`,
+			src:      `{"a":"  x  "}`,
+			expected: "x  ",
+		},
+		{
+			name:     "trim right",
+			exp:      `TRIM_RIGHT(.a, "-")`,
+			src:      `{"a":"--x--"}`,
+			expected: "--x",
+		},
+		{
+			name:     "split",
+			exp:      `SPLIT(.tags, ",")`,
+			src:      `{"tags":"a,b,c"}`,
+			expected: []any{"a", "b", "c"},
+		},
+		{
+			name:     "split missing",
+			exp:      `SPLIT(.missing, ",")`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "split contains",
+			exp:      `SPLIT(.tags, ",") CONTAINS "b"`,
+			src:      `{"tags":"a,b,c"}`,
+			expected: true,
+		},
+		{
+			name:     "join",
+			exp:      `JOIN(.a, "/")`,
+			src:      `{"a":["x","y"]}`,
+			expected: "x/y",
+		},
+		{
+			name:     "join missing",
+			exp:      `JOIN(.missing, "/")`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name: "join non strings",
+			exp:  `JOIN(.a, "/")`,
+			src:  `{"a":["x",1]}`,
+			err:  ErrInvalidArgument{Function: "JOIN", Index: 0, Value: []any{"x", 1.0}, Expected: "array of strings", Span: Span{Start: 0, End: 13}},
+		},
+		{
+			name:     "replace",
+			exp:      `REPLACE(.a, "ü", "ue")`,
+			src:      `{"a":"Zürich"}`,
+			expected: "Zuerich",
+		},
+		{
+			name:     "replace missing",
+			exp:      `REPLACE(.a, .missing, "ue")`,
+			src:      `{"a":"Zürich"}`,
+			expected: nil,
+		},
+		{
+			name:     "index of",
+			exp:      `INDEX_OF(.a, "r")`,
+			src:      `{"a":"Zürich"}`,
+			expected: 2.0,
+		},
+		{
+			name:     "index of missing",
+			exp:      `INDEX_OF(.a, "x")`,
+			src:      `{"a":"Zürich"}`,
+			expected: -1.0,
+		},
+		{
+			name:     "index of null string",
+			exp:      `INDEX_OF(.missing, "x")`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "index of null element",
+			exp:      `INDEX_OF(.a, NULL)`,
+			src:      `{"a":[1,null]}`,
+			expected: 1.0,
+		},
+		{
+			name:     "pad left",
+			exp:      `PAD_LEFT(.a, 5, "0")`,
+			src:      `{"a":"42"}`,
+			expected: "00042",
+		},
+		{
+			name:     "pad left unicode",
+			exp:      `PAD_LEFT(.a, 4, "äb")`,
+			src:      `{"a":"x"}`,
+			expected: "äbäx",
+		},
+		{
+			name:     "pad right",
+			exp:      `PAD_RIGHT(.a, 4)`,
+			src:      `{"a":"ü"}`,
+			expected: "ü   ",
+		},
+		{
+			name:     "pad missing",
+			exp:      `PAD_RIGHT(.missing, 4)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name:     "pad missing width",
+			exp:      `PAD_RIGHT(.a, .missing)`,
+			src:      `{"a":"x"}`,
+			expected: nil,
+		},
+		{
+			name:     "pad wider",
+			exp:      `PAD_RIGHT(.a, 1)`,
+			src:      `{"a":"abc"}`,
+			expected: "abc",
+		},
+		{
+			name: "pad negative",
+			exp:  `PAD_RIGHT(.a, -1)`,
+			src:  `{"a":"abc"}`,
+			err:  ErrInvalidArgument{Function: "PAD_RIGHT", Index: 1, Value: -1.0, Expected: "non-negative integer", Span: Span{Start: 0, End: 17}},
+		},
+		{
+			name:     "repeat",
+			exp:      `REPEAT(.a, 3)`,
+			src:      `{"a":"ab"}`,
+			expected: "ababab",
+		},
+		{
+			name:     "repeat missing",
+			exp:      `REPEAT(.missing, 3)`,
+			src:      `{}`,
+			expected: nil,
+		},
+		{
+			name: "repeat too long",
+			exp:  `REPEAT(.a, 2147483647)`,
+			src:  `{"a":"abcdefgh"}`,
+			err:  ErrResultTooLong{Function: "REPEAT", Length: 17179869176, Span: Span{Start: 0, End: 22}},
+		},
+		{
+			name: "pad too long",
+			exp:  `PAD_LEFT(.a, 2000000)`,
+			src:  `{"a":"x"}`,
+			err:  ErrResultTooLong{Function: "PAD_LEFT", Length: 2000001, Span: Span{Start: 0, End: 21}},
+		},
+		{
+			name:     "nested",
+			exp:      `LEN(TRIM(.a)) > 2`,
+			src:      `{"a":"  abc "}`,
+			expected: true,
+		},
+	})
+}
+
+func TestStringFunctionConstants(t *testing.T) {
+	assert := require.New(t)
+	ex, err := Parse([]byte(`.a == PAD_LEFT(TRIM(" 7 "), 3, "0")`))
+	assert.NoError(err)
+	assert.Equal(`.a == "007"`, format(ex))
+
+	// constant calls failing when parsed are located at the start of the call rather than by a Span
+	_, err = Parse([]byte(`.a == LEN(1)`))
+	var parseErr ParseError
+	assert.True(errors.As(err, &parseErr))
+	assert.Equal(6, parseErr.Offset)
+	assert.EqualError(err, "invalid argument 1 to LEN: expected string, array or object but found `1` (number) at line 1, column 7")
+	var argErr ErrInvalidArgument
+	assert.True(errors.As(err, &argErr))
+	assert.Equal(Span{}, argErr.Span)
+
+	// constant calls with results too long to produce are rejected rather than folded
+	_, err = Parse([]byte(`REPEAT("abcdefgh", 2147483647)`))
+	var lengthErr ErrResultTooLong
+	assert.True(errors.As(err, &lengthErr))
+	assert.Equal(17179869176, lengthErr.Length)
+
+	// lenient calculates invalid arguments as null
+	ex, err = ParseWithOptions([]byte(`LEN(.a)`), ParseOptions{Mode: Lenient})
+	assert.NoError(err)
+	got, err := ex.Calculate([]byte(`{"a":1}`))
+	assert.NoError(err)
+	assert.Nil(got)
+}
@@ -0,0 +1,141 @@
+package express
+
+import "strings"
+
+// literalValue returns the value of an expression when it is a constant known at parse time.
+func literalValue(e Expression) (any, bool) {
+	switch t := e.(type) {
+	case str:
+		return t.s, true
+	case num:
+		return t.n, true
+	case boolean:
+		return t.b, true
+	case null:
+		return nil, true
+	case coercedConstant:
+		return t.value, true
+	case array:
+		arr := make([]any, 0, len(t.vec))
+		for _, v := range t.vec {
+			value, ok := literalValue(v)
+			if !ok {
+				return nil, false
+			}
+			arr = append(arr, value)
+		}
+		return arr, true
+	default:
+		return nil, false
+	}
+}
+
+// selectorSegments splits a gjson selector path into its object keys and array indexes,
+// returning false for gjson syntax such as wildcards, queries and modifiers
+// which has no equivalent outside of gjson.
+func selectorSegments(path string) (segments []string, ok bool) {
+	var b strings.Builder
+	var escaped bool
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case escaped:
+			b.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '.':
+			if b.Len() == 0 {
+				return nil, false
+			}
+			segments = append(segments, b.String())
+			b.Reset()
+		case c == '#' || c == '*' || c == '?' || c == '|':
+			return nil, false
+		case b.Len() == 0 && (c == '@' || c == '!' || c == '[' || c == '{'):
+			return nil, false
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil, false
+	}
+	return append(segments, b.String()), true
+}
+
+func isIndex(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		if !isDigit(segment[i]) {
+			return false
+		}
+	}
+	return len(segment) > 0
+}
+
+// fieldOperands returns the dotted field path and the constant value of a comparison between a selector
+// and a constant, flipped reports whether the constant is the left operand.
+func fieldOperands(left, right Expression) (field string, value any, flipped bool, ok bool) {
+	if field, ok = fieldPath(left); ok {
+		if value, ok = literalValue(right); ok {
+			return field, value, false, true
+		}
+	}
+
+	if field, ok = fieldPath(right); ok {
+		if value, ok = literalValue(left); ok {
+			return field, value, true, true
+		}
+	}
+	return "", nil, false, false
+}
+
+// fieldPath returns the dotted field path of a selector,
+// DateTime coercions are unwrapped as document stores compare dates natively.
+func fieldPath(e Expression) (string, bool) {
+	switch t := e.(type) {
+	case selectorPath:
+		segments, ok := selectorSegments(t.s)
+		if !ok {
+			return "", false
+		}
+
+		for _, segment := range segments {
+			if strings.ContainsRune(segment, '.') {
+				return "", false
+			}
+		}
+		return strings.Join(segments, "."), true
+	case coerceDateTime:
+		return fieldPath(t.value)
+	default:
+		return "", false
+	}
+}
+
+// flattenLogical returns the operands of nested `&&` or `||` expressions of the same kind as the one supplied.
+func flattenLogical(e Expression) []Expression {
+	var operands []Expression
+	var walk func(current Expression)
+	walk = func(current Expression) {
+		switch t := current.(type) {
+		case and:
+			if _, ok := e.(and); ok {
+				walk(t.left)
+				walk(t.right)
+				return
+			}
+		case or:
+			if _, ok := e.(or); ok {
+				walk(t.left)
+				walk(t.right)
+				return
+			}
+		}
+		operands = append(operands, current)
+	}
+
+	walk(e)
+	return operands
+}
@@ -0,0 +1,200 @@
+package express
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind is the type of a Value.
+type Kind uint8
+
+const (
+	// KindNull is a null or missing value.
+	KindNull Kind = iota
+	// KindBool is a boolean.
+	KindBool
+	// KindNumber is a number, held as a float64.
+	KindNumber
+	// KindString is a string.
+	KindString
+	// KindDateTime is a time.Time, such as produced by COERCE _datetime_.
+	KindDateTime
+	// KindArray is an array, held as a []any.
+	KindArray
+	// KindObject is an object, held as a map[string]any.
+	KindObject
+	// KindOther is any other Go value, such as one returned by a custom Function or Coercion.
+	KindOther
+)
+
+// String returns the name of the kind, the same as returned by the TYPEOF function.
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindDateTime:
+		return "datetime"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	default:
+		return "other"
+	}
+}
+
+// typeOf returns the name of the Kind of the value, as returned by TYPEOF and reported in errors,
+// or its Go type for KindOther.
+func typeOf(value any) string {
+	if kind := ValueOf(value).Kind(); kind != KindOther {
+		return kind.String()
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Value is the typed result of calculating an Expression.
+//
+// Its accessors report whether the Value is of the kind requested, numbers of any Go type being returned as a float64.
+type Value struct {
+	value any
+}
+
+// ValueOf returns the Value of a result, such as one returned by Calculate.
+func ValueOf(v any) Value {
+	return Value{value: v}
+}
+
+// Kind returns the kind of the Value.
+func (v Value) Kind() Kind {
+	if _, ok := number(v.value); ok {
+		return KindNumber
+	}
+
+	switch v.value.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case string:
+		return KindString
+	case time.Time:
+		return KindDateTime
+	case []any:
+		return KindArray
+	case map[string]any:
+		return KindObject
+	default:
+		return KindOther
+	}
+}
+
+// Any returns the underlying value, the same as returned by Calculate.
+func (v Value) Any() any {
+	return v.value
+}
+
+// IsNull returns if the Value is null.
+func (v Value) IsNull() bool {
+	return v.value == nil
+}
+
+// Bool returns the Value as a boolean.
+func (v Value) Bool() (bool, bool) {
+	b, ok := v.value.(bool)
+	return b, ok
+}
+
+// Float returns the Value as a number.
+func (v Value) Float() (float64, bool) {
+	return number(v.value)
+}
+
+// Text returns the Value as a string.
+func (v Value) Text() (string, bool) {
+	s, ok := v.value.(string)
+	return s, ok
+}
+
+// Time returns the Value as a datetime.
+func (v Value) Time() (time.Time, bool) {
+	t, ok := v.value.(time.Time)
+	return t, ok
+}
+
+// Array returns the elements of the Value as an array.
+func (v Value) Array() ([]Value, bool) {
+	arr, ok := v.value.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	elems := make([]Value, 0, len(arr))
+	for _, e := range arr {
+		elems = append(elems, ValueOf(e))
+	}
+	return elems, true
+}
+
+// Object returns the members of the Value as an object.
+func (v Value) Object() (map[string]Value, bool) {
+	obj, ok := v.value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	members := make(map[string]Value, len(obj))
+	for k, e := range obj {
+		members[k] = ValueOf(e)
+	}
+	return members, true
+}
+
+// String returns the JSON representation of the Value, datetimes being RFC 3339 strings.
+func (v Value) String() string {
+	return formatValue(v.value)
+}
+
+// CalculateValue executes the parsed expression against the supplied JSON, returning its result as a Value.
+func CalculateValue(e Expression, src []byte) (Value, error) {
+	result, err := e.Calculate(src)
+	if err != nil {
+		return Value{}, err
+	}
+	return ValueOf(result), nil
+}
+
+// CalculateBool executes the parsed expression against the supplied JSON,
+// returning ErrResultType if its result is not a boolean, including when it is null.
+func CalculateBool(e Expression, src []byte) (bool, error) {
+	v, err := CalculateValue(e, src)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.Bool()
+	if !ok {
+		return false, ErrResultType{Expected: KindBool, Value: v}
+	}
+	return b, nil
+}
+
+// CalculateString executes the parsed expression against the supplied JSON,
+// returning ErrResultType if its result is not a string, including when it is null.
+func CalculateString(e Expression, src []byte) (string, error) {
+	v, err := CalculateValue(e, src)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := v.Text()
+	if !ok {
+		return "", ErrResultType{Expected: KindString, Value: v}
+	}
+	return s, nil
+}
@@ -0,0 +1,107 @@
+package express
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValue(t *testing.T) {
+	assert := require.New(t)
+	ex, err := Parse([]byte(`[.a, .b, .c, .d, .e, NULL, COERCE .f _datetime_]`))
+	assert.NoError(err)
+
+	v, err := CalculateValue(ex, []byte(`{"a":true,"b":1.5,"c":"x","d":[1],"e":{"k":"v"},"f":"2024-01-02T03:04:05Z"}`))
+	assert.NoError(err)
+	assert.Equal(KindArray, v.Kind())
+
+	elems, ok := v.Array()
+	assert.True(ok)
+	assert.Len(elems, 7)
+
+	b, ok := elems[0].Bool()
+	assert.True(ok)
+	assert.True(b)
+
+	f, ok := elems[1].Float()
+	assert.True(ok)
+	assert.Equal(1.5, f)
+
+	s, ok := elems[2].Text()
+	assert.True(ok)
+	assert.Equal("x", s)
+	_, ok = elems[2].Float()
+	assert.False(ok)
+
+	arr, ok := elems[3].Array()
+	assert.True(ok)
+	assert.Equal([]Value{ValueOf(1.0)}, arr)
+
+	obj, ok := elems[4].Object()
+	assert.True(ok)
+	assert.Equal(map[string]Value{"k": ValueOf("v")}, obj)
+
+	assert.True(elems[5].IsNull())
+	assert.Equal(KindNull, elems[5].Kind())
+
+	tm, ok := elems[6].Time()
+	assert.True(ok)
+	assert.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), tm.UTC())
+
+	kinds := make([]string, 0, len(elems))
+	for _, e := range elems {
+		kinds = append(kinds, e.Kind().String())
+	}
+	assert.Equal([]string{"bool", "number", "string", "array", "object", "null", "datetime"}, kinds)
+	assert.Equal(`[true,1.5,"x",[1],{"k":"v"},null,"2024-01-02T03:04:05Z"]`, v.String())
+}
+
+func TestValueKinds(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal(KindNumber, ValueOf(3).Kind())
+	assert.Equal(KindOther, ValueOf(struct{}{}).Kind())
+
+	f, ok := ValueOf(uint16(3)).Float()
+	assert.True(ok)
+	assert.Equal(3.0, f)
+}
+
+func TestCalculateTyped(t *testing.T) {
+	tests := []struct {
+		name     string
+		exp      string
+		src      string
+		expected any
+		err      string
+	}{
+		{name: "bool", exp: `.a == 1`, src: `{"a":1}`, expected: true},
+		{name: "bool from string", exp: `.a`, src: `{"a":"true"}`, expected: false, err: "expected a bool result but found `\"true\"` (string)"},
+		{name: "bool from null", exp: `.a`, src: `{}`, expected: false, err: "expected a bool result but found `null` (null)"},
+		{name: "string", exp: `COERCE .a _uppercase_`, src: `{"a":"x"}`, expected: "X"},
+		{name: "string from number", exp: `.a`, src: `{"a":1}`, expected: "", err: "expected a string result but found `1` (number)"},
+		{name: "error", exp: `.a > 1`, src: `{"a":"x"}`, err: "unsupported type comparison: `\"x\" > 1` (string > number) at [0:6]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			ex, err := Parse([]byte(tc.exp))
+			assert.NoError(err)
+
+			var got any
+			if _, isString := tc.expected.(string); isString {
+				got, err = CalculateString(ex, []byte(tc.src))
+			} else {
+				got, err = CalculateBool(ex, []byte(tc.src))
+			}
+
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package express
+
+// mapChildren returns a copy of a built-in expression with each of its child expressions
+// replaced by the result of fn, called in order from left to right.
+// Leaves and custom expressions are returned unchanged.
+func mapChildren(e Expression, fn func(Expression) Expression) Expression {
+	switch t := e.(type) {
+	case between:
+		t.value, t.left, t.right = fn(t.value), fn(t.left), fn(t.right)
+		return t
+	case add:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case sub:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case multi:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case div:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case eq:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case gt:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case gte:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case lt:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case lte:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case or:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case and:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case startsWith:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case endsWith:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case in:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case contains:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case containsAny:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case containsAll:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case not:
+		t.value = fn(t.value)
+		return t
+	case isNull:
+		t.value = fn(t.value)
+		return t
+	case fold:
+		t.value = fn(t.value)
+		return t
+	case isType:
+		t.value = fn(t.value)
+		return t
+	case slice:
+		t.value = fn(t.value)
+		return t
+	case index:
+		t.value = fn(t.value)
+		return t
+	case member:
+		t.value = fn(t.value)
+		return t
+	case setOperation:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case hasKey:
+		t.left, t.right = fn(t.left), fn(t.right)
+		return t
+	case call:
+		args := make([]Expression, 0, len(t.args))
+		for _, arg := range t.args {
+			args = append(args, fn(arg))
+		}
+		t.args = args
+		return t
+	case array:
+		vec := make([]Expression, 0, len(t.vec))
+		for _, v := range t.vec {
+			vec = append(vec, fn(v))
+		}
+		t.vec = vec
+		return t
+	case coerceString:
+		t.value = fn(t.value)
+		return t
+	case coerceDateTime:
+		t.value = fn(t.value)
+		return t
+	case coerceUppercase:
+		t.value = fn(t.value)
+		return t
+	case coerceLowercase:
+		t.value = fn(t.value)
+		return t
+	case coerceNumber:
+		t.value = fn(t.value)
+		return t
+	case coerceTitle:
+		t.value = fn(t.value)
+		return t
+	case coerceSubstr:
+		t.value = fn(t.value)
+		return t
+	case *memo:
+		return &memo{slot: t.slot, value: fn(t.value)}
+	default:
+		return e
+	}
+}
+
+// children returns the child expressions of a built-in expression.
+func children(e Expression) (c []Expression) {
+	mapChildren(e, func(child Expression) Expression {
+		c = append(c, child)
+		return child
+	})
+	return
+}
+
+// withSpan returns a copy of a built-in expression located at the span within the parsed expression.
+// Leaves, constants and custom expressions are returned unchanged.
+func withSpan(e Expression, span Span) Expression {
+	switch t := e.(type) {
+	case between:
+		t.span = span
+		return t
+	case add:
+		t.span = span
+		return t
+	case sub:
+		t.span = span
+		return t
+	case multi:
+		t.span = span
+		return t
+	case div:
+		t.span = span
+		return t
+	case eq:
+		t.span = span
+		return t
+	case gt:
+		t.span = span
+		return t
+	case gte:
+		t.span = span
+		return t
+	case lt:
+		t.span = span
+		return t
+	case lte:
+		t.span = span
+		return t
+	case or:
+		t.span = span
+		return t
+	case and:
+		t.span = span
+		return t
+	case startsWith:
+		t.span = span
+		return t
+	case endsWith:
+		t.span = span
+		return t
+	case in:
+		t.span = span
+		return t
+	case contains:
+		t.span = span
+		return t
+	case containsAny:
+		t.span = span
+		return t
+	case containsAll:
+		t.span = span
+		return t
+	case not:
+		t.span = span
+		return t
+	case coerceString:
+		t.span = span
+		return t
+	case coerceDateTime:
+		t.span = span
+		return t
+	case coerceUppercase:
+		t.span = span
+		return t
+	case coerceLowercase:
+		t.span = span
+		return t
+	case coerceNumber:
+		t.span = span
+		return t
+	case coerceTitle:
+		t.span = span
+		return t
+	case coerceSubstr:
+		t.span = span
+		return t
+	case call:
+		t.span = span
+		return t
+	case slice:
+		t.span = span
+		return t
+	case index:
+		t.span = span
+		return t
+	case member:
+		t.span = span
+		return t
+	case setOperation:
+		t.span = span
+		return t
+	case hasKey:
+		t.span = span
+		return t
+	default:
+		return e
+	}
+}
+
+// withOptions returns a copy of the expression tree with the options applied to each of its built-in operators and coercions.
+// Leaves, constants and custom expressions are returned unchanged.
+func withOptions(e Expression, opts ParseOptions) Expression {
+	e = mapChildren(e, func(child Expression) Expression {
+		return withOptions(child, opts)
+	})
+
+	switch t := e.(type) {
+	case between:
+		t.opts = opts
+		return t
+	case add:
+		t.opts = opts
+		return t
+	case sub:
+		t.opts = opts
+		return t
+	case multi:
+		t.opts = opts
+		return t
+	case div:
+		t.opts = opts
+		return t
+	case eq:
+		t.opts = opts
+		return t
+	case gt:
+		t.opts = opts
+		return t
+	case gte:
+		t.opts = opts
+		return t
+	case lt:
+		t.opts = opts
+		return t
+	case lte:
+		t.opts = opts
+		return t
+	case or:
+		t.opts = opts
+		return t
+	case and:
+		t.opts = opts
+		return t
+	case startsWith:
+		t.opts = opts
+		return t
+	case endsWith:
+		t.opts = opts
+		return t
+	case in:
+		t.opts = opts
+		return t
+	case contains:
+		t.opts = opts
+		return t
+	case containsAny:
+		t.opts = opts
+		return t
+	case containsAll:
+		t.opts = opts
+		return t
+	case not:
+		t.opts = opts
+		return t
+	case coerceString:
+		t.opts = opts
+		return t
+	case coerceDateTime:
+		t.opts = opts
+		return t
+	case coerceUppercase:
+		t.opts = opts
+		return t
+	case coerceLowercase:
+		t.opts = opts
+		return t
+	case coerceNumber:
+		t.opts = opts
+		return t
+	case coerceTitle:
+		t.opts = opts
+		return t
+	case coerceSubstr:
+		t.opts = opts
+		return t
+	case call:
+		t.opts = opts
+		return t
+	case slice:
+		t.opts = opts
+		return t
+	case index:
+		t.opts = opts
+		return t
+	case member:
+		t.opts = opts
+		return t
+	case setOperation:
+		t.opts = opts
+		return t
+	case hasKey:
+		t.opts = opts
+		return t
+	default:
+		return e
+	}
+}